@@ -0,0 +1,411 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	cmwType    = reflect.TypeOf(CMW{})
+	cmwPtrType = reflect.TypeOf(&CMW{})
+	bytesType  = reflect.TypeOf([]byte(nil))
+	stringType = reflect.TypeOf("")
+)
+
+type boundField struct {
+	name  string
+	index int
+	key   any
+
+	omitempty bool
+	tag       bool
+
+	// the declared media type, for []byte fields, or collection type, for
+	// __cmwc_t fields
+	mediaType any
+	ctyp      string
+	ind       Indicator
+
+	isCType bool
+}
+
+func boundFields(t reflect.Type) ([]boundField, error) {
+	var fields []boundField
+
+	// the field bound to each key, including __cmwc_t
+	seen := make(map[any]string)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		spec, ok := sf.Tag.Lookup("cmw")
+		if !ok || spec == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(spec, ",")
+
+		f := boundField{name: sf.Name, index: i}
+
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "":
+			case "omitempty":
+				f.omitempty = true
+			case "tag":
+				f.tag = true
+			default:
+				return nil, fmt.Errorf("field %s: unknown option %q", sf.Name, opt)
+			}
+		}
+
+		if name == CmwCType {
+			if err := bindCType(sf, &f); err != nil {
+				return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+			}
+			if other, found := seen[CmwCType]; found {
+				return nil, fmt.Errorf("field %s: %s already bound to field %s", sf.Name, CmwCType, other)
+			}
+			seen[CmwCType] = sf.Name
+			fields = append(fields, f)
+			continue
+		}
+
+		if !sf.IsExported() {
+			return nil, fmt.Errorf("field %s: unexported", sf.Name)
+		}
+
+		key, err := parseBoundKey(name)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+		if other, found := seen[key]; found {
+			return nil, fmt.Errorf("field %s: key %v already bound to field %s", sf.Name, key, other)
+		}
+		seen[key] = sf.Name
+		f.key = key
+
+		if sf.Type == bytesType {
+			if err := bindMonad(sf, &f); err != nil {
+				return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+			}
+		} else if !isBindableType(sf.Type) {
+			return nil, fmt.Errorf("field %s: unsupported type %s", sf.Name, sf.Type)
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+func bindCType(sf reflect.StructField, f *boundField) error {
+	f.isCType = true
+	f.ctyp = sf.Tag.Get("cmwtype")
+
+	if f.ctyp != "" {
+		if err := validateCollectionType(f.ctyp); err != nil {
+			return err
+		}
+	}
+
+	if sf.Name == "_" {
+		if f.ctyp == "" {
+			return errors.New("marker field without cmwtype")
+		}
+		return nil
+	}
+
+	if sf.Type != stringType || !sf.IsExported() {
+		return errors.New("collection type must be bound to an exported string field")
+	}
+
+	return nil
+}
+
+func bindMonad(sf reflect.StructField, f *boundField) error {
+	mt, ok := sf.Tag.Lookup("cmwtype")
+	if !ok || mt == "" {
+		return errors.New("missing cmwtype for []byte field")
+	}
+
+	if cf, err := strconv.ParseUint(mt, 10, 16); err == nil {
+		f.mediaType = uint16(cf)
+	} else {
+		var t Type
+		if err := t.Set(mt); err != nil {
+			return err
+		}
+		f.mediaType = mt
+	}
+
+	if ind, ok := sf.Tag.Lookup("cmwind"); ok {
+		v, err := ParseIndicator(ind)
+		if err != nil {
+			return err
+		}
+		f.ind = v
+	}
+
+	return nil
+}
+
+func isBindableType(t reflect.Type) bool {
+	if t == cmwType || t == cmwPtrType {
+		return true
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func parseBoundKey(name string) (any, error) {
+	if name == "" {
+		return nil, errors.New("missing key")
+	}
+
+	if u, err := strconv.ParseUint(name, 10, 64); err == nil {
+		return u, nil
+	}
+
+	if i, err := strconv.ParseInt(name, 10, 64); err == nil {
+		return i, nil
+	}
+
+	if err := validateCollectionKey(name); err != nil {
+		return nil, err
+	}
+
+	return name, nil
+}
+
+// Marshal returns the collection CMW bound to the struct (or pointer to
+// struct) v.
+//
+// Marshal and Unmarshal map the items of a collection to the fields of a Go
+// struct according to their "cmw" tags:
+//
+//	type Bundle struct {
+//		_        struct{}   `cmw:"__cmwc_t" cmwtype:"tag:example.com,2025:bundle"`
+//		Evidence []byte     `cmw:"ev" cmwtype:"application/eat+cwt" cmwind:"evidence"`
+//		Platform *cmw.CMW   `cmw:"1,omitempty"`
+//		Sub      *SubBundle `cmw:"sub,omitempty"`
+//	}
+//
+// The "cmw" tag holds the collection key, followed by comma-separated options.
+// A key made of digits (optionally preceded by "-") is an integer key.  The
+// options are:
+//
+//   - omitempty: the item is optional.  Zero fields are not marshaled, and
+//     missing items are not an error when unmarshaling.
+//   - tag: a []byte field is marshaled as a CBOR tag rather than as a record.
+//
+// The supported field types are:
+//
+//   - CMW and *CMW, bound to any item;
+//   - []byte, bound to the value of a monad whose media type (or CoAP
+//     Content-Format number) is declared in the "cmwtype" tag.  The optional
+//     "cmwind" tag holds the indicators set when marshaling (see
+//     ParseIndicator);
+//   - structs and pointers to structs, bound to nested collections.
+//
+// The collection type (__cmwc_t) is bound to a string field tagged
+// `cmw:"__cmwc_t"`.  If the field also has a "cmwtype" tag, that is used when
+// the field is empty, and the collection type is checked against it when
+// marshaling and unmarshaling.  A blank (_) field can be used as a marker for a fixed
+// collection type.
+//
+// Fields without a "cmw" tag, or tagged `cmw:"-"`, are ignored, as are
+// collection items that are not bound to any field.  Binding two fields to the
+// same key (e.g., "1" and "01") is an error.
+func Marshal(v any) (*CMW, error) {
+	rv := reflect.ValueOf(v)
+
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("want struct or pointer to struct, got %T", v)
+	}
+
+	c, err := marshalStruct(rv)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func marshalStruct(rv reflect.Value) (*CMW, error) {
+	fields, err := boundFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	c, _ := NewCollection("")
+
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+
+		if f.isCType {
+			ctyp := f.ctyp
+			if f.name != "_" && fv.String() != "" {
+				ctyp = fv.String()
+			}
+			if f.ctyp != "" && ctyp != f.ctyp {
+				return nil, fmt.Errorf("field %s: want collection type %q, got %q", f.name, f.ctyp, ctyp)
+			}
+			if ctyp != "" {
+				if err := validateCollectionType(ctyp); err != nil {
+					return nil, fmt.Errorf("field %s: %w", f.name, err)
+				}
+			}
+			c.collection.ctyp = ctyp
+			continue
+		}
+
+		if fv.IsZero() {
+			if f.omitempty {
+				continue
+			}
+			return nil, fmt.Errorf("field %s: missing item %v", f.name, f.key)
+		}
+
+		item, err := marshalField(f, fv)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.name, err)
+		}
+
+		if err := c.AddCollectionItem(f.key, item); err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.name, err)
+		}
+	}
+
+	return c, nil
+}
+
+func marshalField(f boundField, fv reflect.Value) (*CMW, error) {
+	switch fv.Type() {
+	case cmwType:
+		c := fv.Interface().(CMW)
+		return &c, nil
+	case cmwPtrType:
+		return fv.Interface().(*CMW), nil
+	case bytesType:
+		m, err := NewMonad(f.mediaType, bytes.Clone(fv.Bytes()), f.ind)
+		if err != nil {
+			return nil, err
+		}
+		if f.tag {
+			m.UseCBORTagFormat()
+		}
+		return m, nil
+	}
+
+	if fv.Kind() == reflect.Pointer {
+		fv = fv.Elem()
+	}
+
+	return marshalStruct(fv)
+}
+
+// Unmarshal populates the struct pointed to by v with the items of the
+// collection c, using the bindings described in Marshal.  Monad values bound
+// to []byte fields are copied; CMW fields share the data of c.
+func Unmarshal(c CMW, v any) error {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("want non-nil pointer to struct, got %T", v)
+	}
+
+	return unmarshalStruct(c, rv.Elem())
+}
+
+func unmarshalStruct(c CMW, rv reflect.Value) error {
+	if c.kind != KindCollection {
+		return fmt.Errorf("want collection, got %q", c.kind)
+	}
+
+	fields, err := boundFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	keys := keyIndex(c.collection)
+
+	for _, f := range fields {
+		if f.isCType {
+			if f.ctyp != "" && c.collection.ctyp != f.ctyp {
+				return fmt.Errorf("field %s: want collection type %q, got %q", f.name, f.ctyp, c.collection.ctyp)
+			}
+			if f.name != "_" {
+				rv.Field(f.index).SetString(c.collection.ctyp)
+			}
+			continue
+		}
+
+		k, found := keys[f.key]
+		if !found {
+			if f.omitempty {
+				continue
+			}
+			return fmt.Errorf("field %s: missing item %v", f.name, f.key)
+		}
+
+		if err := unmarshalField(f, c.collection.cmap[k], rv.Field(f.index)); err != nil {
+			return fmt.Errorf("field %s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalField(f boundField, item CMW, fv reflect.Value) error {
+	switch fv.Type() {
+	case cmwType:
+		fv.Set(reflect.ValueOf(item))
+		return nil
+	case cmwPtrType:
+		fv.Set(reflect.ValueOf(&item))
+		return nil
+	case bytesType:
+		if item.kind != KindMonad {
+			return fmt.Errorf("want monad, got %q", item.kind)
+		}
+
+		want := normalizeMediaType(Type{f.mediaType}.String())
+		if got := normalizeMediaType(item.monad.typ.String()); got != want {
+			return fmt.Errorf("want media type %q, got %q", want, got)
+		}
+
+		if item.monad.src != nil {
+			return errors.New("monad value is backed by a reader")
+		}
+
+		fv.SetBytes(bytes.Clone(item.monad.val))
+		return nil
+	}
+
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+
+	return unmarshalStruct(item, fv)
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSubBundle struct {
+	CType  string `cmw:"__cmwc_t"`
+	Claims []byte `cmw:"claims" cmwtype:"application/eat-ucs+json" cmwind:"attestation results"`
+}
+
+type testBundle struct {
+	_        struct{}       `cmw:"__cmwc_t" cmwtype:"tag:example.com,2025:bundle"`
+	Evidence []byte         `cmw:"ev" cmwtype:"application/eat+cwt" cmwind:"evidence"`
+	Tagged   []byte         `cmw:"1,tag" cmwtype:"30001"`
+	Platform *CMW           `cmw:"platform,omitempty"`
+	Sub      *testSubBundle `cmw:"sub,omitempty"`
+	Ignored  string
+}
+
+func Test_Marshal_Unmarshal_roundtrip(t *testing.T) {
+	in := testBundle{
+		Evidence: []byte{0xd2, 0x84},
+		Tagged:   []byte{0x23, 0x47, 0xda, 0x55},
+		Platform: mustNewMonad(t, "application/vnd.a", []byte{0x01}),
+		Sub: &testSubBundle{
+			CType:  "tag:example.com,2025:sub",
+			Claims: []byte(`{"eat_nonce": ...}`),
+		},
+		Ignored: "not bound",
+	}
+
+	c, err := Marshal(&in)
+	require.NoError(t, err)
+
+	ctyp, err := c.GetCollectionType()
+	require.NoError(t, err)
+	assert.Equal(t, "tag:example.com,2025:bundle", ctyp)
+
+	ev, err := c.GetCollectionItem("ev")
+	require.NoError(t, err)
+	ind, err := ev.GetMonadIndicator()
+	require.NoError(t, err)
+	assert.Equal(t, Indicator(Evidence), ind)
+
+	tagged, err := c.GetCollectionItem(uint64(1))
+	require.NoError(t, err)
+	assert.Equal(t, FormatCBORTag, tagged.GetFormat())
+
+	// round-trip through CBOR, which decodes integer keys and CoAP
+	// Content-Formats as uint64
+	var decoded CMW
+	require.NoError(t, decoded.UnmarshalCBOR(mustMarshalCBOR(t, c)))
+
+	var out testBundle
+	require.NoError(t, Unmarshal(decoded, &out))
+
+	assert.Equal(t, in.Evidence, out.Evidence)
+	assert.Equal(t, in.Tagged, out.Tagged)
+	assert.Equal(t, in.Sub, out.Sub)
+	assert.Empty(t, out.Ignored)
+	require.NotNil(t, out.Platform)
+	assert.True(t, in.Platform.Equal(*out.Platform, IgnoreFormat()))
+}
+
+func Test_Marshal_omitempty(t *testing.T) {
+	c, err := Marshal(testBundle{
+		Evidence: []byte{0x01},
+		Tagged:   []byte{0x02},
+	})
+	require.NoError(t, err)
+
+	meta, err := c.GetCollectionMeta()
+	require.NoError(t, err)
+	assert.Len(t, meta, 2)
+
+	var out testBundle
+	require.NoError(t, Unmarshal(*c, &out))
+	assert.Nil(t, out.Platform)
+	assert.Nil(t, out.Sub)
+}
+
+func Test_Marshal_ko(t *testing.T) {
+	_, err := Marshal(testBundle{Evidence: []byte{0x01}})
+	assert.EqualError(t, err, "field Tagged: missing item 1")
+
+	_, err = Marshal(42)
+	assert.EqualError(t, err, "want struct or pointer to struct, got int")
+
+	_, err = Marshal(struct {
+		V string `cmw:"v"`
+	}{})
+	assert.EqualError(t, err, "field V: unsupported type string")
+
+	_, err = Marshal(struct {
+		V []byte `cmw:"v"`
+	}{})
+	assert.EqualError(t, err, "field V: missing cmwtype for []byte field")
+
+	_, err = Marshal(struct {
+		_ struct{} `cmw:"__cmwc_t"`
+	}{})
+	assert.EqualError(t, err, "field _: marker field without cmwtype")
+
+	_, err = Marshal(struct {
+		V []byte `cmw:"v,bogus" cmwtype:"application/vnd.a"`
+	}{})
+	assert.EqualError(t, err, `field V: unknown option "bogus"`)
+
+	_, err = Marshal(struct {
+		A []byte `cmw:"a" cmwtype:"application/vnd.a"`
+		B []byte `cmw:"a" cmwtype:"application/vnd.b"`
+	}{A: []byte{0x01}, B: []byte{0x02}})
+	assert.EqualError(t, err, "field B: key a already bound to field A")
+
+	_, err = Marshal(struct {
+		A *CMW `cmw:"1"`
+		B *CMW `cmw:"01"`
+	}{})
+	assert.EqualError(t, err, "field B: key 1 already bound to field A")
+
+	_, err = Marshal(struct {
+		_ struct{} `cmw:"__cmwc_t" cmwtype:"tag:example.com,2025:a"`
+		T string   `cmw:"__cmwc_t"`
+	}{})
+	assert.EqualError(t, err, "field T: __cmwc_t already bound to field _")
+
+	_, err = Marshal(struct {
+		T string `cmw:"__cmwc_t" cmwtype:"tag:example.com,2025:a"`
+		V *CMW   `cmw:"v"`
+	}{
+		T: "tag:example.com,2025:b",
+		V: mustNewMonad(t, "application/vnd.a", []byte{0x01}),
+	})
+	assert.EqualError(t, err, `field T: want collection type "tag:example.com,2025:a", got "tag:example.com,2025:b"`)
+}
+
+func Test_Unmarshal_ko(t *testing.T) {
+	var out testBundle
+
+	assert.EqualError(t, Unmarshal(CMW{}, out), "want non-nil pointer to struct, got cmw.testBundle")
+
+	m := mustNewMonad(t, "application/vnd.a", []byte{0x01})
+	assert.EqualError(t, Unmarshal(*m, &out), `want collection, got "monad"`)
+
+	c, err := Build().
+		Type("tag:example.com,2025:other").
+		Monad("ev", "application/eat+cwt", []byte{0x01}).
+		Done()
+	require.NoError(t, err)
+	assert.EqualError(t, Unmarshal(*c, &out),
+		`field _: want collection type "tag:example.com,2025:bundle", got "tag:example.com,2025:other"`)
+
+	c, err = Build().
+		Type("tag:example.com,2025:bundle").
+		Monad("ev", "application/eat+cwt", []byte{0x01}).
+		Done()
+	require.NoError(t, err)
+	assert.EqualError(t, Unmarshal(*c, &out), "field Tagged: missing item 1")
+
+	c, err = Build().
+		Type("tag:example.com,2025:bundle").
+		Monad("ev", "application/eat+jwt", []byte{0x01}).
+		Tag(1, uint16(30001), []byte{0x02}).
+		Done()
+	require.NoError(t, err)
+	assert.EqualError(t, Unmarshal(*c, &out),
+		`field Evidence: want media type "application/eat+cwt", got "application/eat+jwt"`)
+}
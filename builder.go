@@ -0,0 +1,123 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CollectionBuilder assembles a collection CMW from multiple goroutines, e.g.,
+// appraisal workers that each contribute a result.  All its methods are safe
+// for concurrent use.  Unlike a CMW, which must not be modified concurrently,
+// the builder serializes access to the items.
+type CollectionBuilder struct {
+	mu sync.RWMutex
+	c  collection
+}
+
+// NewCollectionBuilder instantiates a builder for a collection with the
+// supplied __cmwc_t.  Pass an empty string to avoid setting __cmwc_t.
+func NewCollectionBuilder(cmwct string) (*CollectionBuilder, error) {
+	c, err := NewCollection(cmwct)
+	if err != nil {
+		return nil, err
+	}
+	return &CollectionBuilder{c: c.collection}, nil
+}
+
+// Add adds a deep copy of node (see CMW.Clone) under key, so that the caller
+// can keep using node.  Unlike CMW.AddCollectionItem, it fails if an item with
+// the same key already exists, since concurrent producers overwriting each
+// other's results is most likely a bug.  Integer keys of any Go integer type
+// are accepted, and keys with the same integer value are duplicates.
+func (o *CollectionBuilder) Add(key any, node *CMW) error {
+	k, err := builderKey(key)
+	if err != nil {
+		return err
+	}
+
+	if node == nil {
+		return errors.New("nil node")
+	}
+
+	item := node.clone()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, found := o.c.cmap[k]; found {
+		return fmt.Errorf("duplicate key %v", k)
+	}
+
+	return o.c.addItem(k, &item)
+}
+
+// Get returns a deep copy of the item associated with key
+func (o *CollectionBuilder) Get(key any) (*CMW, error) {
+	k, err := builderKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	v, err := o.c.getItem(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Clone(), nil
+}
+
+// Len returns the number of items added so far
+func (o *CollectionBuilder) Len() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return len(o.c.cmap)
+}
+
+// Meta retrieves a (sorted) list of the keys and associated types of the items
+// added so far
+func (o *CollectionBuilder) Meta() []Meta {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.c.getMeta()
+}
+
+// MarshalCBOR serializes the items added so far as a CBOR collection
+func (o *CollectionBuilder) MarshalCBOR() ([]byte, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.c.MarshalCBOR()
+}
+
+// MarshalJSON serializes the items added so far as a JSON collection
+func (o *CollectionBuilder) MarshalJSON() ([]byte, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.c.MarshalJSON()
+}
+
+// Build validates the items added so far and returns them as an immutable
+// collection.  The builder can still be used afterwards: further additions do
+// not affect the returned snapshot.
+func (o *CollectionBuilder) Build() (Frozen, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	c := CMW{kind: KindCollection, collection: o.c}
+
+	if err := c.validate(); err != nil {
+		return Frozen{}, err
+	}
+
+	return c.Freeze()
+}
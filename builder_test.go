@@ -0,0 +1,136 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run with -race
+func Test_CollectionBuilder_concurrent(t *testing.T) {
+	const workers = 16
+
+	b, err := NewCollectionBuilder("tag:example.com,2025:appraisal")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		// writers
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			m, err := NewMonad("application/eat+cwt", []byte{byte(i)}, AttestationResults)
+			assert.NoError(t, err)
+			assert.NoError(t, b.Add(fmt.Sprintf("worker-%02d", i), m))
+
+			// modifying the node after adding it does not affect the builder
+			assert.NoError(t, m.setIndicators(Evidence))
+		}(i)
+
+		// readers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for _, m := range b.Meta() {
+				item, err := b.Get(m.Key)
+				assert.NoError(t, err)
+				assert.Equal(t, KindMonad, item.GetKind())
+			}
+
+			_, err := b.MarshalCBOR()
+			assert.NoError(t, err)
+
+			_, err = b.MarshalJSON()
+			assert.NoError(t, err)
+
+			if f, err := b.Build(); err == nil {
+				_, err = f.MarshalCBOR()
+				assert.NoError(t, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, workers, b.Len())
+
+	f, err := b.Build()
+	require.NoError(t, err)
+
+	meta, err := f.GetCollectionMeta()
+	require.NoError(t, err)
+	assert.Len(t, meta, workers)
+
+	for i := 0; i < workers; i++ {
+		item, err := f.GetCollectionItem(fmt.Sprintf("worker-%02d", i))
+		require.NoError(t, err)
+
+		v, err := item.GetMonadValue()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{byte(i)}, v)
+
+		ind, err := item.GetMonadIndicator()
+		require.NoError(t, err)
+		assert.Equal(t, Indicator(AttestationResults), ind)
+	}
+
+	ctyp, err := f.GetCollectionType()
+	require.NoError(t, err)
+	assert.Equal(t, "tag:example.com,2025:appraisal", ctyp)
+}
+
+func Test_CollectionBuilder_Build_snapshot(t *testing.T) {
+	b, err := NewCollectionBuilder("")
+	require.NoError(t, err)
+
+	require.NoError(t, b.Add("a", mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+
+	f, err := b.Build()
+	require.NoError(t, err)
+
+	require.NoError(t, b.Add("b", mustNewMonad(t, "application/vnd.b", []byte{0x02})))
+
+	meta, err := f.GetCollectionMeta()
+	require.NoError(t, err)
+	assert.Equal(t, []Meta{{"a", KindMonad}}, meta)
+	assert.Equal(t, 2, b.Len())
+}
+
+func Test_CollectionBuilder_ko(t *testing.T) {
+	_, err := NewCollectionBuilder("not a URI")
+	assert.EqualError(t, err, `invalid collection type: "not a URI".  URI is not absolute`)
+
+	b, err := NewCollectionBuilder("")
+	require.NoError(t, err)
+
+	_, err = b.Build()
+	assert.EqualError(t, err, "empty CMW collection")
+
+	m := mustNewMonad(t, "application/vnd.a", []byte{0x01})
+
+	require.NoError(t, b.Add("a", m))
+	assert.EqualError(t, b.Add("a", m), "duplicate key a")
+	assert.EqualError(t, b.Add(CmwCType, m), "invalid key: bad collection key: __cmwc_t is reserved")
+	assert.EqualError(t, b.Add("b", nil), "nil node")
+	assert.EqualError(t, b.Add([]byte("k"), m), "invalid key: unknown collection key type: want string or int, got []uint8")
+
+	// integer keys with the same value are duplicates, whatever their Go type
+	require.NoError(t, b.Add(int64(1), m))
+	assert.EqualError(t, b.Add(uint64(1), m), "duplicate key 1")
+	assert.EqualError(t, b.Add(1, m), "duplicate key 1")
+
+	_, err = b.Get(int8(1))
+	assert.NoError(t, err)
+
+	_, err = b.Get("missing")
+	assert.EqualError(t, err, `item not found for key "missing"`)
+}
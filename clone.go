@@ -0,0 +1,114 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Clone returns a deep copy of the CMW: collection maps and monad values are
+// duplicated, so that the copy and the original can be modified independently.
+// Values backed by a reader (see NewMonadFromReader) are not read: the copy
+// shares the reader with the original.
+func (o CMW) Clone() *CMW {
+	c := o.clone()
+	return &c
+}
+
+func (o CMW) clone() CMW {
+	c := o
+
+	switch o.kind {
+	case KindMonad:
+		c.monad.val = bytes.Clone(o.monad.val)
+	case KindCollection:
+		if o.collection.cmap != nil {
+			c.collection.cmap = make(map[any]CMW, len(o.collection.cmap))
+			for k, v := range o.collection.cmap {
+				c.collection.cmap[k] = v.clone()
+			}
+		}
+	}
+
+	return c
+}
+
+// Frozen is a read-only snapshot of a CMW.  It is safe for concurrent use by
+// multiple goroutines, and none of its getters returns data that aliases the
+// snapshot.
+type Frozen struct {
+	c CMW
+}
+
+// Freeze returns a read-only snapshot of a deep copy of the CMW (see Clone).
+// Later changes to the CMW do not affect the snapshot.  Monads whose value is
+// backed by a reader cannot be frozen, since reading is not safe for
+// concurrent use.
+func (o CMW) Freeze() (Frozen, error) {
+	if err := checkFreezable(o); err != nil {
+		return Frozen{}, err
+	}
+	return Frozen{o.clone()}, nil
+}
+
+func checkFreezable(c CMW) error {
+	switch c.kind {
+	case KindMonad:
+		if c.monad.src != nil {
+			return errors.New("cannot freeze a monad value backed by a reader")
+		}
+	case KindCollection:
+		for k, v := range c.collection.cmap {
+			if err := checkFreezable(v); err != nil {
+				return fmt.Errorf("collection item %v: %w", k, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Thaw returns a mutable deep copy of the snapshot
+func (o Frozen) Thaw() *CMW { return o.c.Clone() }
+
+func (o Frozen) GetKind() Kind     { return o.c.GetKind() }
+func (o Frozen) GetFormat() Format { return o.c.GetFormat() }
+
+func (o Frozen) GetMonadType() (string, error) { return o.c.GetMonadType() }
+
+// GetMonadValue returns a copy of the monad value
+func (o Frozen) GetMonadValue() ([]byte, error) {
+	v, err := o.c.GetMonadValue()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(v), nil
+}
+
+func (o Frozen) GetMonadIndicator() (Indicator, error) { return o.c.GetMonadIndicator() }
+
+func (o Frozen) GetCollectionType() (string, error) { return o.c.GetCollectionType() }
+
+// GetCollectionItem returns a snapshot of the item associated with key, which
+// shares the (read-only) data of the parent
+func (o Frozen) GetCollectionItem(key any) (Frozen, error) {
+	v, err := o.c.GetCollectionItem(key)
+	if err != nil {
+		return Frozen{}, err
+	}
+	return Frozen{*v}, nil
+}
+
+func (o Frozen) GetCollectionMeta() ([]Meta, error) { return o.c.GetCollectionMeta() }
+
+func (o Frozen) MarshalJSON() ([]byte, error) { return o.c.MarshalJSON() }
+func (o Frozen) MarshalCBOR() ([]byte, error) { return o.c.MarshalCBOR() }
+
+// Format implements fmt.Formatter (see CMW.Format)
+func (o Frozen) Format(f fmt.State, verb rune) { o.c.Format(f, verb) }
+
+// LogValue implements slog.LogValuer
+func (o Frozen) LogValue() slog.Value { return o.c.LogValue() }
@@ -0,0 +1,159 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetCollectionItem_aliasing(t *testing.T) {
+	root := makeCMWCollection()
+	before := mustMarshalCBOR(t, root)
+
+	sub, err := root.GetCollectionItem("murmurless")
+	require.NoError(t, err)
+
+	// the returned item shares its map with the parent...
+	require.NoError(t, sub.AddCollectionItem("extra", mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+	assert.NotEqual(t, before, mustMarshalCBOR(t, root))
+
+	// ...unless it is cloned
+	root = makeCMWCollection()
+
+	sub, err = root.GetCollectionItem("murmurless")
+	require.NoError(t, err)
+
+	require.NoError(t, sub.Clone().AddCollectionItem("extra", mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+	assert.Equal(t, before, mustMarshalCBOR(t, root))
+}
+
+func Test_Clone(t *testing.T) {
+	orig := makeCMWCollection()
+	before := mustMarshalCBOR(t, orig)
+
+	c := orig.Clone()
+	assert.True(t, orig.Equal(*c))
+
+	// mutate the clone at every level
+	require.NoError(t, c.AddCollectionItem("new", mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+
+	sub, err := c.GetCollectionItem("murmurless")
+	require.NoError(t, err)
+	require.NoError(t, sub.AddCollectionItem("new", mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+
+	m, err := c.GetCollectionItem("bretwaldadom")
+	require.NoError(t, err)
+	v, err := m.GetMonadValue()
+	require.NoError(t, err)
+	v[0] = 0xff
+
+	assert.Equal(t, before, mustMarshalCBOR(t, orig))
+	assert.Len(t, Diff(*orig, *c), 3)
+}
+
+func Test_Clone_monad(t *testing.T) {
+	value := []byte{0x01, 0x02}
+	m := mustNewMonad(t, "application/vnd.a", value, Evidence)
+
+	c := m.Clone()
+	value[0] = 0xff
+
+	v, err := c.GetMonadValue()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02}, v)
+
+	ind, err := c.GetMonadIndicator()
+	require.NoError(t, err)
+	assert.Equal(t, Indicator(Evidence), ind)
+}
+
+func Test_Freeze(t *testing.T) {
+	orig := makeCMWCollection()
+	before := mustMarshalCBOR(t, orig)
+
+	f, err := orig.Freeze()
+	require.NoError(t, err)
+
+	// changes to the original do not affect the snapshot
+	require.NoError(t, orig.AddCollectionItem("new", mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+
+	b, err := f.MarshalCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, before, b)
+
+	item, err := f.GetCollectionItem("bretwaldadom")
+	require.NoError(t, err)
+	assert.Equal(t, KindMonad, item.GetKind())
+
+	// values returned by the snapshot are copies
+	v, err := item.GetMonadValue()
+	require.NoError(t, err)
+	v[0] = 0xff
+
+	v, err = item.GetMonadValue()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xa1, 0x0a}, v)
+
+	// thawing gives an independent, mutable copy
+	thawed := f.Thaw()
+	require.NoError(t, thawed.AddCollectionItem("new", mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+
+	b, err = f.MarshalCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, before, b)
+
+	assert.Equal(t, fmt.Sprint(makeCMWCollection()), fmt.Sprint(f))
+}
+
+func Test_Freeze_concurrent(t *testing.T) {
+	f, err := makeCMWCollection().Freeze()
+	require.NoError(t, err)
+
+	expected, err := f.MarshalCBOR()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			b, err := f.MarshalCBOR()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, b)
+
+			meta, err := f.GetCollectionMeta()
+			assert.NoError(t, err)
+
+			for _, m := range meta {
+				item, err := f.GetCollectionItem(m.Key)
+				assert.NoError(t, err)
+				_ = fmt.Sprintf("%+v", item)
+			}
+
+			_ = f.Thaw()
+		}()
+	}
+
+	wg.Wait()
+}
+
+func Test_Freeze_reader_backed(t *testing.T) {
+	m, err := NewMonadFromReader("application/vnd.a", bytes.NewReader([]byte{0x01}), 1)
+	require.NoError(t, err)
+
+	c, err := NewCollection("")
+	require.NoError(t, err)
+	require.NoError(t, c.AddCollectionItem("m", m))
+
+	_, err = c.Freeze()
+	assert.EqualError(t, err, "collection item m: cannot freeze a monad value backed by a reader")
+}
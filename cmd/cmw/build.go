@@ -0,0 +1,468 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/veraison/cmw"
+	"gopkg.in/yaml.v3"
+)
+
+const buildSynopsis = `[-manifest <file>] [-item <key>=<media-type>[:<indicators>]@<file>]... [-type [<path>=]<uri-or-oid>]... [-format cbor|json] [-o <file>]
+
+Items and types can be supplied via a YAML or JSON manifest, via flags, or both
+(flags are applied after the manifest).  Nested collection items are addressed
+with "/"-separated key paths; a "#<n>" path segment denotes the integer key n.
+A media type can also be given as a CoAP Content-Format number.  Indicators
+are a comma-separated list of names (evidence, endorsements,
+reference-values, attestation-results, trust-anchors) or a number.
+
+Manifest example:
+
+  type: tag:example.com,2025:bundle
+  items:
+    ev:
+      media-type: application/eat+cwt
+      value-file: evidence.cwt      # relative to the manifest
+      indicators: [evidence]
+      tag: true                     # use the CBOR Tag format
+    1:                              # integer key
+      content-format: 10571
+      value: oQo                    # base64
+    sub:
+      type: 1.2.3.4
+      items: { ... }
+`
+
+// stringList is a repeatable flag
+type stringList []string
+
+func (o *stringList) String() string     { return strings.Join(*o, ", ") }
+func (o *stringList) Set(v string) error { *o = append(*o, v); return nil }
+
+func runBuild(args []string, env *environment) error {
+	var items, types stringList
+
+	fs := newFlagSet("build", buildSynopsis, env)
+	manifestFile := fs.String("manifest", "", "YAML or JSON manifest `file`")
+	fs.Var(&items, "item", "add a monad item: `key=media-type[:indicators]@file` (repeatable)")
+	fs.Var(&types, "type", "set __cmwc_t of the root or of the collection at path: `[path=]uri-or-oid` (repeatable)")
+	format := fs.String("format", "cbor", "output format: cbor or json")
+	outFile := fs.String("o", "-", "output `file`")
+
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	if *format != "cbor" && *format != "json" {
+		return usageErrorf("unknown format %q: want cbor or json", *format)
+	}
+
+	root := newBuildNode()
+
+	if *manifestFile != "" {
+		if err := root.loadManifest(*manifestFile); err != nil {
+			return fmt.Errorf("loading manifest: %w", err)
+		}
+	}
+
+	for _, v := range items {
+		if err := root.addItemFlag(v); err != nil {
+			return usageErrorf("-item %q: %v", v, err)
+		}
+	}
+
+	for _, v := range types {
+		if err := root.setTypeFlag(v); err != nil {
+			return usageErrorf("-type %q: %v", v, err)
+		}
+	}
+
+	c, err := root.toCMW()
+	if err != nil {
+		return err
+	}
+
+	if err := c.ValidateCollection(); err != nil {
+		return err
+	}
+
+	var out []byte
+
+	switch *format {
+	case "cbor":
+		out, err = c.MarshalCBOR()
+	case "json":
+		out, err = c.MarshalJSON()
+	}
+
+	if err != nil {
+		return fmt.Errorf("encoding collection: %w", err)
+	}
+
+	return writeOutput(*outFile, out, env)
+}
+
+// buildNode is either a collection (items != nil) or a monad
+type buildNode struct {
+	ctype string
+	items map[any]*buildNode
+
+	monad *cmw.CMW
+}
+
+func newBuildNode() *buildNode {
+	return &buildNode{items: make(map[any]*buildNode)}
+}
+
+func (o *buildNode) isCollection() bool { return o.items != nil }
+
+// lookup returns the collection at path, creating intermediate collections as
+// needed
+func (o *buildNode) lookup(path []any) (*buildNode, error) {
+	n := o
+
+	for i, k := range path {
+		next, ok := n.items[k]
+		if !ok {
+			next = newBuildNode()
+			n.items[k] = next
+		}
+		if !next.isCollection() {
+			return nil, fmt.Errorf("%s is a monad, not a collection", formatPath(path[:i+1]))
+		}
+		n = next
+	}
+
+	return n, nil
+}
+
+func (o *buildNode) toCMW() (*cmw.CMW, error) {
+	if !o.isCollection() {
+		return o.monad, nil
+	}
+
+	c, err := cmw.NewCollection(o.ctype)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]any, 0, len(o.items))
+	for k := range o.items {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+
+	for _, k := range keys {
+		item, err := o.items[k].toCMW()
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", k, err)
+		}
+		if err := c.AddCollectionItem(k, item); err != nil {
+			return nil, fmt.Errorf("%v: %w", k, err)
+		}
+	}
+
+	return c, nil
+}
+
+// parseKeyPath splits a "/"-separated key path; "#<n>" segments are integer
+// keys
+func parseKeyPath(s string) ([]any, error) {
+	if s == "" {
+		return nil, errors.New("empty key")
+	}
+
+	var path []any
+
+	for _, seg := range strings.Split(s, "/") {
+		if strings.HasPrefix(seg, "#") {
+			n, err := strconv.ParseUint(seg[1:], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad integer key %q", seg)
+			}
+			path = append(path, n)
+			continue
+		}
+		if seg == "" {
+			return nil, fmt.Errorf("empty segment in key path %q", s)
+		}
+		path = append(path, seg)
+	}
+
+	return path, nil
+}
+
+func formatPath(path []any) string {
+	var a []string
+	for _, k := range path {
+		if n, ok := k.(uint64); ok {
+			a = append(a, fmt.Sprintf("#%d", n))
+		} else {
+			a = append(a, fmt.Sprint(k))
+		}
+	}
+	return strings.Join(a, "/")
+}
+
+// addItemFlag processes a "key=media-type[:indicators]@file" item spec
+func (o *buildNode) addItemFlag(v string) error {
+	key, rest, ok := strings.Cut(v, "=")
+	if !ok {
+		return errors.New("missing '='")
+	}
+
+	at := strings.LastIndex(rest, "@")
+	if at < 0 {
+		return errors.New("missing '@<file>'")
+	}
+
+	spec, file := rest[:at], rest[at+1:]
+
+	mt, ind := spec, cmw.Indicator(cmw.IndicatorNone)
+	if colon := lastUnquotedColon(spec); colon >= 0 {
+		i, err := cmw.ParseIndicator(spec[colon+1:])
+		if err != nil {
+			return err
+		}
+		mt, ind = spec[:colon], i
+	}
+
+	path, err := parseKeyPath(key)
+	if err != nil {
+		return err
+	}
+
+	val, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	m, err := cmw.NewMonad(parseMediaType(mt), val, ind)
+	if err != nil {
+		return err
+	}
+
+	parent, err := o.lookup(path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+
+	parent.items[path[len(path)-1]] = &buildNode{monad: m}
+
+	return nil
+}
+
+// lastUnquotedColon returns the index of the last ':' in s that is not part of
+// a quoted media type parameter value, or -1.  A ':' cannot appear unquoted in
+// a media type, so it separates the indicators.
+func lastUnquotedColon(s string) int {
+	idx, quoted := -1, false
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				idx = i
+			}
+		}
+	}
+
+	return idx
+}
+
+// setTypeFlag processes a "[path=]uri-or-oid" type spec
+func (o *buildNode) setTypeFlag(v string) error {
+	target := o
+
+	if key, ctype, ok := strings.Cut(v, "="); ok && !strings.Contains(key, ":") {
+		path, err := parseKeyPath(key)
+		if err != nil {
+			return err
+		}
+		if target, err = o.lookup(path); err != nil {
+			return err
+		}
+		v = ctype
+	}
+
+	target.ctype = v
+
+	return nil
+}
+
+// parseMediaType turns decimal numbers into CoAP Content-Format IDs
+func parseMediaType(s string) any {
+	if n, err := strconv.ParseUint(s, 10, 16); err == nil {
+		return uint16(n)
+	}
+	return s
+}
+
+type manifest struct {
+	Type  string               `yaml:"type"`
+	Items map[any]manifestItem `yaml:"items"`
+}
+
+type manifestItem struct {
+	// monad
+	MediaType     string   `yaml:"media-type"`
+	ContentFormat *uint16  `yaml:"content-format"`
+	Value         string   `yaml:"value"`
+	ValueFile     string   `yaml:"value-file"`
+	Indicators    []string `yaml:"indicators"`
+	Tag           bool     `yaml:"tag"`
+
+	// collection
+	Type  string               `yaml:"type"`
+	Items map[any]manifestItem `yaml:"items"`
+}
+
+func (o *buildNode) loadManifest(name string) error {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	// JSON is (for our purposes) a subset of YAML
+	var m manifest
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	o.ctype = m.Type
+
+	return o.addManifestItems(m.Items, nil, filepath.Dir(name))
+}
+
+// addManifestItems adds the manifest items of the collection at path (nil for
+// the root), prefixing errors with the path of the offending item
+func (o *buildNode) addManifestItems(items map[any]manifestItem, path []any, dir string) error {
+	for k, v := range items {
+		key, err := manifestKey(k)
+		if err != nil {
+			if len(path) == 0 {
+				return err
+			}
+			return fmt.Errorf("%s: %w", formatPath(path), err)
+		}
+
+		itemPath := append(append([]any{}, path...), key)
+
+		if v.Items != nil {
+			if v.MediaType != "" || v.ContentFormat != nil || v.Value != "" || v.ValueFile != "" {
+				return fmt.Errorf("%s: an item cannot be both a collection and a monad", formatPath(itemPath))
+			}
+			sub := newBuildNode()
+			sub.ctype = v.Type
+			o.items[key] = sub
+			if err := sub.addManifestItems(v.Items, itemPath, dir); err != nil {
+				return err
+			}
+			continue
+		}
+
+		m, err := newManifestMonad(v, dir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", formatPath(itemPath), err)
+		}
+
+		o.items[key] = &buildNode{monad: m}
+	}
+
+	return nil
+}
+
+func newManifestMonad(v manifestItem, dir string) (*cmw.CMW, error) {
+	var typ any
+
+	switch {
+	case v.MediaType != "" && v.ContentFormat != nil:
+		return nil, errors.New("media-type and content-format are mutually exclusive")
+	case v.MediaType != "":
+		typ = v.MediaType
+	case v.ContentFormat != nil:
+		typ = *v.ContentFormat
+	default:
+		return nil, errors.New("missing media-type or content-format (or items, for a collection)")
+	}
+
+	var (
+		val []byte
+		err error
+	)
+
+	switch {
+	case v.Value != "" && v.ValueFile != "":
+		return nil, errors.New("value and value-file are mutually exclusive")
+	case v.Value != "":
+		if val, err = decodeBase64(v.Value); err != nil {
+			return nil, fmt.Errorf("decoding value: %w", err)
+		}
+	case v.ValueFile != "":
+		f := v.ValueFile
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(dir, f)
+		}
+		if val, err = os.ReadFile(f); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("missing value or value-file")
+	}
+
+	ind, err := cmw.ParseIndicator(strings.Join(v.Indicators, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := cmw.NewMonad(typ, val, ind)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Tag {
+		m.UseCBORTagFormat()
+	}
+
+	return m, nil
+}
+
+func manifestKey(k any) (any, error) {
+	switch t := k.(type) {
+	case string:
+		return t, nil
+	case int:
+		if t < 0 {
+			return nil, fmt.Errorf("negative key %d", t)
+		}
+		return uint64(t), nil
+	case uint64:
+		return t, nil
+	default:
+		return nil, fmt.Errorf("unsupported key %v (%T): want string or unsigned integer", t, t)
+	}
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "+/") {
+		return base64.RawStdEncoding.DecodeString(s)
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/cmw"
+)
+
+const testManifest = `
+type: tag:ietf.org,2024:X
+items:
+  bretwaldadom:
+    media-type: application/eat-ucs+cbor
+    value: oQo
+  murmurless:
+    type: tag:ietf.org,2024:Y
+    items:
+      polyscopic:
+        media-type: application/eat-ucs+json
+        value-file: polyscopic.json
+        indicators: [attestation-results]
+  photoelectrograph:
+    media-type: application/eat-ucs+cbor
+    value: gngY
+    indicators: [reference-values, endorsements]
+`
+
+func Test_build_manifest(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "polyscopic.json", `{"eat_nonce": ...}`)
+	m := writeTestFile(t, dir, "manifest.yaml", testManifest)
+
+	code, stdout, stderr := runCLI("", "build", "-manifest", m, "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	assert.JSONEq(t, testCollection, stdout)
+
+	// flags are applied on top of the manifest
+	code, stdout, stderr = runCLI("", "build", "-manifest", m,
+		"-type", "murmurless=1.2.3.4", "-item", "murmurless/#7=30001:evidence@"+m)
+	require.Equal(t, exitOK, code, stderr)
+
+	var c cmw.CMW
+	require.NoError(t, c.UnmarshalCBOR([]byte(stdout)))
+	assert.Equal(t, cmw.FormatCBORCollection, c.GetFormat())
+
+	sub, err := c.GetCollectionItem("murmurless")
+	require.NoError(t, err)
+	ctyp, err := sub.GetCollectionType()
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3.4", ctyp)
+
+	seven, err := sub.GetCollectionItem(uint64(7))
+	require.NoError(t, err)
+	typ, _ := seven.GetMonadType()
+	assert.Equal(t, "30001", typ)
+	ind, _ := seven.GetMonadIndicator()
+	assert.Equal(t, cmw.Indicator(cmw.Evidence), ind)
+}
+
+func Test_build_manifest_json(t *testing.T) {
+	dir := t.TempDir()
+	m := writeTestFile(t, dir, "manifest.json", `{
+		"type": "1.2.3.4",
+		"items": {
+			"1": { "content-format": 30001, "value": "I0faVQ" },
+			"a": { "media-type": "application/vnd.a", "value": "YQ", "indicators": ["evidence", "4"] }
+		}
+	}`)
+
+	code, stdout, stderr := runCLI("", "build", "-manifest", m, "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	assert.JSONEq(t, `{"__cmwc_t":"1.2.3.4","1":[30001,"I0faVQ"],"a":["application/vnd.a","YQ",4]}`, stdout)
+}
+
+func Test_build_flags(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.bin", "a")
+	out := filepath.Join(dir, "out.json")
+
+	code, _, stderr := runCLI("", "build", "-format", "json", "-o", out,
+		"-type", "tag:example.com,2025:x",
+		"-item", `a=application/eat+cwt; eat_profile="tag:psacertified.org,2019:psa#legacy"@`+a,
+		"-item", "b=application/vnd.b:evidence,endorsements@"+a,
+		"-item", "c/d=application/vnd.d@"+a,
+		"-type", "c=1.2.3")
+	require.Equal(t, exitOK, code, stderr)
+
+	actual, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"__cmwc_t": "tag:example.com,2025:x",
+		"a": ["application/eat+cwt; eat_profile=\"tag:psacertified.org,2019:psa#legacy\"", "YQ"],
+		"b": ["application/vnd.b", "YQ", 6],
+		"c": { "__cmwc_t": "1.2.3", "d": ["application/vnd.d", "YQ"] }
+	}`, string(actual))
+}
+
+func Test_build_failures(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.bin", "a")
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		err  string
+	}{
+		{"no items", []string{}, exitFailure, "empty CMW collection"},
+		{"bad format", []string{"-format", "xml"}, exitUsage, `unknown format "xml"`},
+		{"missing file", []string{"-item", "a=application/vnd.a"}, exitUsage, "missing '@<file>'"},
+		{"missing key", []string{"-item", "application/vnd.a@" + a}, exitUsage, "missing '='"},
+		{"bad indicators", []string{"-item", "x=application/eat+cwt:evidnce@" + a}, exitUsage, `unknown indicator "evidnce"`},
+		{"bad int key", []string{"-item", "#x=application/vnd.a@" + a}, exitUsage, `bad integer key "#x"`},
+		{"monad as parent", []string{"-item", "a=application/vnd.a@" + a, "-item", "a/b=application/vnd.b@" + a}, exitUsage, "a is a monad, not a collection"},
+		{"bad ctype", []string{"-type", "not absolute", "-item", "a=application/vnd.a@" + a}, exitFailure, "invalid collection type"},
+		{"bad manifest", []string{"-manifest", writeTestFile(t, dir, "m1.yaml", "items:\n  a:\n    value: YQ\n")}, exitFailure, "a: missing media-type"},
+		{"bad nested manifest item", []string{"-manifest", writeTestFile(t, dir, "m4.yaml", "items:\n  a:\n    items:\n      7:\n        value: YQ\n")}, exitFailure, "a/#7: missing media-type"},
+		{"ambiguous manifest item", []string{"-manifest", writeTestFile(t, dir, "m2.yaml", "items:\n  a:\n    media-type: x/y\n    items: {}\n")}, exitFailure, "both a collection and a monad"},
+		{"bad manifest indicator", []string{"-manifest", writeTestFile(t, dir, "m3.yaml", "items:\n  a:\n    media-type: x/y\n    value: YQ\n    indicators: [bogus]\n")}, exitFailure, `unknown indicator "bogus"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runCLI("", append([]string{"build"}, tt.args...)...)
+			assert.Equal(t, tt.code, code)
+			assert.Contains(t, stderr, tt.err)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/veraison/cmw"
+)
+
+// readInput reads the named file, or stdin if the name is empty or "-"
+func readInput(name string, env *environment) ([]byte, error) {
+	if name == "" || name == "-" {
+		return io.ReadAll(env.stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// writeOutput writes to the named file, or stdout if the name is empty or "-"
+func writeOutput(name string, b []byte, env *environment) error {
+	if name == "" || name == "-" {
+		_, err := env.stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(name, b, 0o644)
+}
+
+// printTree writes a human-readable, indented rendition of the supplied CMW
+func printTree(w io.Writer, c *cmw.CMW) error {
+	return printNode(w, c, "", 0)
+}
+
+func printNode(w io.Writer, c *cmw.CMW, label string, depth int) error {
+	indent := strings.Repeat("  ", depth)
+
+	switch c.GetKind() {
+	case cmw.KindMonad:
+		typ, _ := c.GetMonadType()
+		val, _ := c.GetMonadValue()
+		ind, _ := c.GetMonadIndicator()
+
+		fmt.Fprintf(w, "%s%smonad (%s) %s, %d bytes", indent, label, c.GetFormat(), typ, len(val))
+		if !ind.Empty() {
+			fmt.Fprintf(w, " [%s]", ind)
+		}
+		fmt.Fprintln(w)
+	case cmw.KindCollection:
+		ctyp, _ := c.GetCollectionType()
+
+		fmt.Fprintf(w, "%s%scollection (%s)", indent, label, c.GetFormat())
+		if ctyp != "" {
+			fmt.Fprintf(w, " %s", ctyp)
+		}
+		fmt.Fprintln(w)
+
+		meta, err := c.GetCollectionMeta()
+		if err != nil {
+			return err
+		}
+
+		for _, m := range meta {
+			item, err := c.GetCollectionItem(m.Key)
+			if err != nil {
+				return err
+			}
+			if err := printNode(w, item, fmt.Sprintf("%v: ", m.Key), depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown CMW kind")
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+// Command cmw manipulates RATS conceptual message wrappers from the command
+// line.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// exit codes
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+type command struct {
+	summary string
+	run     func(args []string, env *environment) error
+}
+
+var commands = map[string]command{
+	"build":  {"assemble a collection CMW from a manifest or flags", runBuild},
+	"sign":   {"sign a CMW with a JWK or PEM private key", runSign},
+	"verify": {"verify a signed CMW with a JWK or PEM public key", runVerify},
+	"x509":   {"embed and extract CMWs in X.509 certificates and CSRs", runX509},
+}
+
+type environment struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// errUsage is returned (wrapped) by subcommands when invoked incorrectly
+var errUsage = errors.New("usage error")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	env := &environment{stdin, stdout, stderr}
+
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	name := args[0]
+
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(stdout)
+		return exitOK
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "cmw: unknown command %q\n", name)
+		usage(stderr)
+		return exitUsage
+	}
+
+	if err := cmd.run(args[1:], env); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "cmw %s: %v\n", name, err)
+		if errors.Is(err, errUsage) {
+			return exitUsage
+		}
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+func usage(w io.Writer) {
+	var names []string
+	for k := range commands {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: cmw <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, n := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", n, commands[n].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Use "cmw <command> -h" for more information about a command.`)
+}
+
+// newFlagSet creates a flag set for the named subcommand that reports errors
+// instead of exiting
+func newFlagSet(name, synopsis string, env *environment) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "usage: cmw %s %s\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func usageErrorf(format string, a ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, a...))
+}
+
+// parseFlags parses the subcommand arguments and returns the positional ones,
+// of which there must be at most max
+func parseFlags(fs *flag.FlagSet, args []string, max int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	if fs.NArg() > max {
+		return nil, usageErrorf("unexpected arguments: %s", strings.Join(fs.Args()[max:], " "))
+	}
+
+	return fs.Args(), nil
+}
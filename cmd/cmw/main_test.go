@@ -0,0 +1,147 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testES256Key = `{
+	"kty": "EC",
+	"crv": "P-256",
+	"x": "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+	"y": "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+	"d": "870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE",
+	"kid": "1"
+}`
+
+var testES256PubKey = `-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEMKBCTNIcKUSDii11ySs3526iDZ8A
+iTo7Tu6KPAqv7D7gS2XpJFbZiItSs3m9+9Ue6GnvHw/GW2ZZaVtszggXIw==
+-----END PUBLIC KEY-----
+`
+
+const testCollection = `{"__cmwc_t":"tag:ietf.org,2024:X","bretwaldadom":["application/eat-ucs+cbor","oQo"],"murmurless":{"__cmwc_t":"tag:ietf.org,2024:Y","polyscopic":["application/eat-ucs+json","eyJlYXRfbm9uY2UiOiAuLi59",8]},"photoelectrograph":["application/eat-ucs+cbor","gngY",3]}`
+
+const testCollectionTree = `collection (JSON collection) tag:ietf.org,2024:X
+  bretwaldadom: monad (JSON record) application/eat-ucs+cbor, 2 bytes
+  murmurless: collection (JSON collection) tag:ietf.org,2024:Y
+    polyscopic: monad (JSON record) application/eat-ucs+json, 18 bytes [attestation results]
+  photoelectrograph: monad (JSON record) application/eat-ucs+cbor, 3 bytes [endorsements, reference values]
+`
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	p := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	return p
+}
+
+// runCLI invokes the CLI and returns the exit code, stdout and stderr
+func runCLI(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func Test_usage(t *testing.T) {
+	code, _, stderr := runCLI("")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "usage: cmw <command> [arguments]")
+
+	code, stdout, _ := runCLI("", "help")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "sign")
+	assert.Contains(t, stdout, "verify")
+
+	code, _, stderr = runCLI("", "frobnicate")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `cmw: unknown command "frobnicate"`)
+}
+
+func Test_sign_verify_roundtrip(t *testing.T) {
+	dir := t.TempDir()
+	skey := writeTestFile(t, dir, "key.jwk", testES256Key)
+	vkey := writeTestFile(t, dir, "key.pem", testES256PubKey)
+	in := writeTestFile(t, dir, "cmw.json", testCollection)
+
+	for _, format := range []string{"cbor", "json"} {
+		t.Run(format, func(t *testing.T) {
+			signed := filepath.Join(dir, "signed."+format)
+
+			code, _, stderr := runCLI("", "sign", "-key", skey, "-format", format, "-o", signed, in)
+			require.Equal(t, exitOK, code, stderr)
+
+			code, stdout, stderr := runCLI("", "verify", "-key", vkey, signed)
+			require.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, strings.ReplaceAll(testCollectionTree, "JSON", strings.ToUpper(format)), stdout)
+
+			// same, via stdin
+			sb, err := os.ReadFile(signed)
+			require.NoError(t, err)
+
+			code, stdout, _ = runCLI(string(sb), "verify", "-q", "-key", vkey)
+			assert.Equal(t, exitOK, code)
+			assert.Empty(t, stdout)
+		})
+	}
+}
+
+func Test_verify_failures(t *testing.T) {
+	dir := t.TempDir()
+	skey := writeTestFile(t, dir, "key.jwk", testES256Key)
+	vkey := writeTestFile(t, dir, "key.pem", testES256PubKey)
+	in := writeTestFile(t, dir, "cmw.json", testCollection)
+
+	code, signed, stderr := runCLI("", "sign", "-key", skey, in)
+	require.Equal(t, exitOK, code, stderr)
+
+	tampered := []byte(signed)
+	tampered[len(tampered)-1] ^= 0xff
+
+	code, _, stderr = runCLI(string(tampered), "verify", "-key", vkey)
+	assert.Equal(t, exitFailure, code)
+	assert.Equal(t, "cmw verify: signed-cbor-cmw signature verification failed: verification error\n", stderr)
+
+	code, _, stderr = runCLI(testCollection, "verify", "-key", vkey)
+	assert.Equal(t, exitFailure, code)
+	assert.Equal(t, "cmw verify: want signed-cbor-cmw or signed-json-cmw, got envelope \"none\"\n", stderr)
+
+	code, _, stderr = runCLI(signed, "verify", "-key", vkey, "-alg", "ES384")
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, "algorithm mismatch: verifier ES384: header ES256")
+
+	code, _, stderr = runCLI(signed, "verify", "-key", filepath.Join(dir, "nope"))
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, "cmw verify: reading key")
+}
+
+func Test_usage_failures(t *testing.T) {
+	dir := t.TempDir()
+	skey := writeTestFile(t, dir, "key.jwk", testES256Key)
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"sign"}, "missing mandatory -key"},
+		{[]string{"sign", "-key", skey, "-format", "xml"}, `unknown format "xml"`},
+		{[]string{"sign", "-key", skey, "-alg", "HS256"}, `unsupported COSE algorithm "HS256"`},
+		{[]string{"sign", "-key", skey, "a", "b"}, "unexpected arguments: b"},
+		{[]string{"verify", "-nope"}, "flag provided but not defined: -nope"},
+		{[]string{"verify"}, "missing mandatory -key"},
+	}
+
+	for _, tt := range tests {
+		code, _, stderr := runCLI(testCollection, tt.args...)
+		assert.Equal(t, exitUsage, code, tt.args)
+		assert.Contains(t, stderr, tt.err, tt.args)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto"
+	"fmt"
+	"os"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/veraison/cmw"
+	cose "github.com/veraison/go-cose"
+)
+
+func runSign(args []string, env *environment) error {
+	fs := newFlagSet("sign", "-key <file> [-alg <alg>] [-format cbor|json] [-o <file>] [<cmw-file>]", env)
+	keyFile := fs.String("key", "", "JWK or PEM private key `file` (mandatory)")
+	algName := fs.String("alg", "", "signature algorithm (default: derived from the key)")
+	format := fs.String("format", "cbor", "signed CMW format: cbor (COSE_Sign1) or json (JWS)")
+	outFile := fs.String("o", "-", "output `file`")
+
+	pos, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if *keyFile == "" {
+		return usageErrorf("missing mandatory -key")
+	}
+
+	if *format != "cbor" && *format != "json" {
+		return usageErrorf("unknown format %q: want cbor or json", *format)
+	}
+
+	kb, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return fmt.Errorf("reading key: %w", err)
+	}
+
+	key, err := cmw.ParseSigningKey(kb)
+	if err != nil {
+		return fmt.Errorf("loading key: %w", err)
+	}
+
+	var in string
+	if len(pos) > 0 {
+		in = pos[0]
+	}
+
+	b, err := readInput(in, env)
+	if err != nil {
+		return fmt.Errorf("reading CMW: %w", err)
+	}
+
+	var c cmw.CMW
+	if err := c.Deserialize(b); err != nil {
+		return fmt.Errorf("decoding CMW: %w", err)
+	}
+
+	var signed []byte
+
+	switch *format {
+	case "cbor":
+		signed, err = signCBOR(&c, key, *algName)
+	case "json":
+		signed, err = signJSON(&c, key, *algName)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*outFile, signed, env)
+}
+
+func signCBOR(c *cmw.CMW, key crypto.Signer, algName string) ([]byte, error) {
+	alg, err := coseAlgorithm(algName, key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := cose.NewSigner(alg, key)
+	if err != nil {
+		return nil, fmt.Errorf("creating COSE signer: %w", err)
+	}
+
+	signed, err := c.SignCBOR(signer)
+	if err != nil {
+		return nil, fmt.Errorf("signing CMW: %w", err)
+	}
+
+	return signed, nil
+}
+
+func signJSON(c *cmw.CMW, key crypto.Signer, algName string) ([]byte, error) {
+	alg, err := jwsAlgorithm(algName, key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := c.SignJSON(alg, key)
+	if err != nil {
+		return nil, fmt.Errorf("signing CMW: %w", err)
+	}
+
+	return signed, nil
+}
+
+var coseAlgorithms = []cose.Algorithm{
+	cose.AlgorithmES256,
+	cose.AlgorithmES384,
+	cose.AlgorithmES512,
+	cose.AlgorithmEdDSA,
+	cose.AlgorithmPS256,
+	cose.AlgorithmPS384,
+	cose.AlgorithmPS512,
+}
+
+// coseAlgorithm resolves the named COSE algorithm, or the default for the key if
+// the name is empty
+func coseAlgorithm(name string, pub crypto.PublicKey) (cose.Algorithm, error) {
+	if name == "" {
+		return cmw.DefaultCOSEAlgorithm(pub)
+	}
+
+	for _, alg := range coseAlgorithms {
+		if alg.String() == name {
+			return alg, nil
+		}
+	}
+
+	return 0, usageErrorf("unsupported COSE algorithm %q", name)
+}
+
+// jwsAlgorithm resolves the named JWS algorithm, or the default for the key if
+// the name is empty
+func jwsAlgorithm(name string, pub crypto.PublicKey) (jwa.SignatureAlgorithm, error) {
+	if name == "" {
+		return cmw.DefaultJWSAlgorithm(pub)
+	}
+
+	var alg jwa.SignatureAlgorithm
+	if err := alg.Accept(name); err != nil || alg == jwa.NoSignature {
+		return "", usageErrorf("unsupported JWS algorithm %q", name)
+	}
+
+	return alg, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/veraison/cmw"
+	cose "github.com/veraison/go-cose"
+)
+
+func runVerify(args []string, env *environment) error {
+	fs := newFlagSet("verify", "-key <file> [-alg <alg>] [<signed-cmw-file>]", env)
+	keyFile := fs.String("key", "", "JWK or PEM public key, private key or certificate `file` (mandatory)")
+	algName := fs.String("alg", "", "signature algorithm (default: derived from the key)")
+	quiet := fs.Bool("q", false, "do not print the verified CMW")
+
+	pos, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if *keyFile == "" {
+		return usageErrorf("missing mandatory -key")
+	}
+
+	kb, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return fmt.Errorf("reading key: %w", err)
+	}
+
+	pub, err := cmw.ParseVerificationKey(kb)
+	if err != nil {
+		return fmt.Errorf("loading key: %w", err)
+	}
+
+	var in string
+	if len(pos) > 0 {
+		in = pos[0]
+	}
+
+	b, err := readInput(in, env)
+	if err != nil {
+		return fmt.Errorf("reading signed CMW: %w", err)
+	}
+
+	var c cmw.CMW
+
+	switch e := cmw.SniffDetailed(b).Envelope; e {
+	case cmw.EnvelopeSignedCBOR:
+		alg, err := coseAlgorithm(*algName, pub)
+		if err != nil {
+			return err
+		}
+
+		verifier, err := cose.NewVerifier(alg, pub)
+		if err != nil {
+			return fmt.Errorf("creating COSE verifier: %w", err)
+		}
+
+		if err := c.VerifyCBOR(verifier, b); err != nil {
+			return err
+		}
+	case cmw.EnvelopeSignedJSON:
+		alg, err := jwsAlgorithm(*algName, pub)
+		if err != nil {
+			return err
+		}
+
+		if err := c.VerifyJSON(alg, pub, bytes.TrimSpace(b)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("want signed-cbor-cmw or signed-json-cmw, got envelope %q", e)
+	}
+
+	if *quiet {
+		return nil
+	}
+
+	return printTree(env.stdout, &c)
+}
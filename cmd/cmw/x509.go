@@ -0,0 +1,265 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/veraison/cmw"
+)
+
+var x509Commands = map[string]command{
+	"extract":   {"extract the CMWs carried in a certificate, chain or CSR", runX509Extract},
+	"extension": {"wrap a CMW in a DER-encoded id-pe-cmw extension", runX509Extension},
+}
+
+func runX509(args []string, env *environment) error {
+	if len(args) == 0 {
+		x509Usage(env.stderr)
+		return usageErrorf("missing x509 subcommand")
+	}
+
+	name := args[0]
+
+	if name == "help" || name == "-h" || name == "--help" {
+		x509Usage(env.stdout)
+		return flag.ErrHelp
+	}
+
+	cmd, ok := x509Commands[name]
+	if !ok {
+		x509Usage(env.stderr)
+		return usageErrorf("unknown x509 subcommand %q", name)
+	}
+
+	return cmd.run(args[1:], env)
+}
+
+func x509Usage(w io.Writer) {
+	var names []string
+	for k := range x509Commands {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: cmw x509 <subcommand> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "subcommands:")
+	for _, n := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", n, x509Commands[n].summary)
+	}
+}
+
+func runX509Extract(args []string, env *environment) error {
+	fs := newFlagSet("x509 extract", "[-format tree|json|cbor] [<der-or-pem-file>]", env)
+	format := fs.String("format", "tree", "output format: tree, json (one CMW per line) or cbor (CBOR sequence)")
+
+	pos, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "tree", "json", "cbor":
+	default:
+		return usageErrorf("unknown format %q: want tree, json or cbor", *format)
+	}
+
+	var in string
+	if len(pos) > 0 {
+		in = pos[0]
+	}
+
+	b, err := readInput(in, env)
+	if err != nil {
+		return fmt.Errorf("reading input: %w", err)
+	}
+
+	certs, csrs, err := parseX509Artefacts(b)
+	if err != nil {
+		return err
+	}
+
+	var found []extracted
+
+	if len(certs) > 0 {
+		layers, err := cmw.DecodeX509Chain(certs)
+		if err != nil {
+			return err
+		}
+		for _, l := range layers {
+			label := fmt.Sprintf("layer %d (certificate %d, %s)", l.Layer, l.CertIndex, l.Cert.Subject)
+			if l.Anomalies != cmw.LayerAnomalyNone {
+				label += fmt.Sprintf(" [%s]", l.Anomalies)
+			}
+			found = append(found, extracted{label, l.CMW})
+		}
+	}
+
+	for i, csr := range csrs {
+		items, err := cmw.DecodeCertificateRequest(csr)
+		if err != nil {
+			return fmt.Errorf("CSR %d: %w", i, err)
+		}
+		for _, item := range items {
+			label := fmt.Sprintf("CSR %d (%s %d)", i, item.Source, item.Index)
+			found = append(found, extracted{label, item.CMW})
+		}
+	}
+
+	if len(found) == 0 {
+		return errors.New("no id-pe-cmw extension found")
+	}
+
+	return printExtracted(env.stdout, found, *format)
+}
+
+type extracted struct {
+	label string
+	cmw   *cmw.CMW
+}
+
+func printExtracted(w io.Writer, found []extracted, format string) error {
+	for _, e := range found {
+		switch format {
+		case "tree":
+			fmt.Fprintf(w, "%s:\n", e.label)
+			if err := printNode(w, e.cmw, "", 1); err != nil {
+				return err
+			}
+		case "json":
+			b, err := e.cmw.MarshalJSON()
+			if err != nil {
+				return fmt.Errorf("%s: %w", e.label, err)
+			}
+			fmt.Fprintf(w, "%s\n", b)
+		case "cbor":
+			b, err := e.cmw.MarshalCBOR()
+			if err != nil {
+				return fmt.Errorf("%s: %w", e.label, err)
+			}
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseX509Artefacts parses PEM (any number of CERTIFICATE and CERTIFICATE
+// REQUEST blocks) or DER (one or more concatenated certificates, or one CSR)
+// input
+func parseX509Artefacts(b []byte) ([]*x509.Certificate, []*x509.CertificateRequest, error) {
+	var (
+		certs []*x509.Certificate
+		csrs  []*x509.CertificateRequest
+	)
+
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, nil, errors.New("empty input")
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(b), []byte("-----BEGIN ")) {
+		if certs, err := x509.ParseCertificates(b); err == nil {
+			return certs, nil, nil
+		}
+		csr, err := x509.ParseCertificateRequest(b)
+		if err != nil {
+			return nil, nil, errors.New("input is neither a DER certificate (chain) nor a DER CSR")
+		}
+		return nil, []*x509.CertificateRequest{csr}, nil
+	}
+
+	for rest := b; ; {
+		var blk *pem.Block
+		if blk, rest = pem.Decode(rest); blk == nil {
+			break
+		}
+
+		switch blk.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(blk.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("certificate %d: %w", len(certs), err)
+			}
+			certs = append(certs, cert)
+		case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+			csr, err := x509.ParseCertificateRequest(blk.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("CSR %d: %w", len(csrs), err)
+			}
+			csrs = append(csrs, csr)
+		}
+	}
+
+	if len(certs) == 0 && len(csrs) == 0 {
+		return nil, nil, errors.New("no CERTIFICATE or CERTIFICATE REQUEST PEM block found")
+	}
+
+	return certs, csrs, nil
+}
+
+func runX509Extension(args []string, env *environment) error {
+	fs := newFlagSet("x509 extension", "[-format json|cbor] [-critical] [-pem] [-o <file>] [<cmw-file>]", env)
+	format := fs.String("format", "cbor", "serialization of the wrapped CMW: json (UTF8String) or cbor (OCTET STRING)")
+	critical := fs.Bool("critical", false, "mark the extension as critical (not recommended)")
+	armour := fs.Bool("pem", false, `PEM-armour the output (label "CMW EXTENSION")`)
+	outFile := fs.String("o", "-", "output `file`")
+
+	pos, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	var choice cmw.Choice
+
+	switch *format {
+	case "json":
+		choice = cmw.ChoiceJson
+	case "cbor":
+		choice = cmw.ChoiceCbor
+	default:
+		return usageErrorf("unknown format %q: want json or cbor", *format)
+	}
+
+	var in string
+	if len(pos) > 0 {
+		in = pos[0]
+	}
+
+	b, err := readInput(in, env)
+	if err != nil {
+		return fmt.Errorf("reading CMW: %w", err)
+	}
+
+	var c cmw.CMW
+	if err := c.Deserialize(b); err != nil {
+		return fmt.Errorf("decoding CMW: %w", err)
+	}
+
+	extn, err := c.EncodeX509Extension(choice, *critical)
+	if err != nil {
+		return err
+	}
+
+	der, err := asn1.Marshal(*extn)
+	if err != nil {
+		return fmt.Errorf("DER encoding the extension: %w", err)
+	}
+
+	if *armour {
+		der = pem.EncodeToMemory(&pem.Block{Type: "CMW EXTENSION", Bytes: der})
+	}
+
+	return writeOutput(*outFile, der, env)
+}
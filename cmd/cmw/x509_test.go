@@ -0,0 +1,134 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/cmw"
+)
+
+const testCertCBOR = "../../testdata/x509-cert+cbor-cmw.der"
+
+func Test_x509_extract_certificate(t *testing.T) {
+	code, stdout, stderr := runCLI("", "x509", "extract", testCertCBOR)
+	require.Equal(t, exitOK, code, stderr)
+
+	expected := `layer 0 (certificate 0, O=Acme\, Inc.,L=San Francisco,C=US):
+  collection (CBOR collection)
+    1: monad (CBOR record) application/rim+cose, 7 bytes [endorsements, reference values]
+    2: monad (CBOR tag) 29884, 4 bytes
+    s: monad (CBOR record) 30001, 4 bytes
+`
+	assert.Equal(t, expected, stdout)
+
+	// PEM input via stdin, CBOR output
+	der, err := os.ReadFile(testCertCBOR)
+	require.NoError(t, err)
+
+	armoured := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	code, stdout, stderr = runCLI(string(armoured), "x509", "extract", "-format", "cbor")
+	require.Equal(t, exitOK, code, stderr)
+
+	var c cmw.CMW
+	require.NoError(t, c.UnmarshalCBOR([]byte(stdout)))
+	assert.Equal(t, cmw.KindCollection, c.GetKind())
+}
+
+func Test_x509_extract_csr(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var c cmw.CMW
+	require.NoError(t, c.UnmarshalJSON([]byte(testCollection)))
+
+	tmpl := &x509.CertificateRequest{Subject: pkix.Name{CommonName: "attester"}}
+	der, err := cmw.CreateCertificateRequestWithEvidence(rand.Reader, tmpl, key, cmw.ChoiceJson, &c, &c)
+	require.NoError(t, err)
+
+	armoured := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+
+	code, stdout, stderr := runCLI(string(armoured), "x509", "extract", "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, testCollection+"\n"+testCollection+"\n", stdout)
+
+	// DER
+	code, stdout, stderr = runCLI(string(der), "x509", "extract")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "CSR 0 (evidence attribute 1):\n  collection (JSON collection) tag:ietf.org,2024:X\n")
+}
+
+func Test_x509_extension(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestFile(t, dir, "cmw.json", testCollection)
+	out := filepath.Join(dir, "extn.der")
+
+	code, _, stderr := runCLI("", "x509", "extension", "-format", "json", "-critical", "-o", out, in)
+	require.Equal(t, exitOK, code, stderr)
+
+	der, err := os.ReadFile(out)
+	require.NoError(t, err)
+
+	var extn pkix.Extension
+	rest, err := asn1.Unmarshal(der, &extn)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.True(t, extn.Critical)
+
+	c, err := cmw.DecodeX509Extension(extn)
+	require.NoError(t, err)
+	assert.Equal(t, cmw.FormatJSONCollection, c.GetFormat())
+
+	code, stdout, stderr := runCLI(testCollection, "x509", "extension", "-pem")
+	require.Equal(t, exitOK, code, stderr)
+
+	blk, _ := pem.Decode([]byte(stdout))
+	require.NotNil(t, blk)
+	assert.Equal(t, "CMW EXTENSION", blk.Type)
+
+	var extn2 pkix.Extension
+	_, err = asn1.Unmarshal(blk.Bytes, &extn2)
+	require.NoError(t, err)
+	assert.False(t, extn2.Critical)
+
+	c, err = cmw.DecodeX509Extension(extn2)
+	require.NoError(t, err)
+	assert.Equal(t, cmw.FormatCBORCollection, c.GetFormat())
+}
+
+func Test_x509_failures(t *testing.T) {
+	tests := []struct {
+		stdin string
+		args  []string
+		code  int
+		err   string
+	}{
+		{"", []string{"x509"}, exitUsage, "missing x509 subcommand"},
+		{"", []string{"x509", "nope"}, exitUsage, `unknown x509 subcommand "nope"`},
+		{"", []string{"x509", "extract", "-format", "xml"}, exitUsage, `unknown format "xml"`},
+		{"", []string{"x509", "extract"}, exitFailure, "empty input"},
+		{"garbage", []string{"x509", "extract"}, exitFailure, "neither a DER certificate (chain) nor a DER CSR"},
+		{"-----BEGIN CMW-----\n-----END CMW-----\n", []string{"x509", "extract"}, exitFailure, "no CERTIFICATE or CERTIFICATE REQUEST PEM block found"},
+		{"", []string{"x509", "extension", "-format", "xml"}, exitUsage, `unknown format "xml"`},
+		{"garbage", []string{"x509", "extension"}, exitFailure, "decoding CMW"},
+	}
+
+	for _, tt := range tests {
+		code, _, stderr := runCLI(tt.stdin, tt.args...)
+		assert.Equal(t, tt.code, code, tt.args)
+		assert.Contains(t, stderr, tt.err, tt.args)
+	}
+}
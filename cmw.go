@@ -5,7 +5,11 @@ import (
 	"fmt"
 )
 
-// CMW holds the internal representation of a RATS conceptual message wrapper
+// CMW holds the internal representation of a RATS conceptual message wrapper.
+//
+// Copying a CMW is shallow: the copies share the collection maps and the monad
+// values.  Use Clone for an independent copy, or Freeze for a read-only
+// snapshot that can be shared across goroutines.
 type CMW struct {
 	kind Kind
 
@@ -78,6 +82,8 @@ func (o Format) String() string {
 	}
 }
 
+// NewMonad instantiates a new monad CMW.  The value is not copied: the caller
+// must not modify it afterwards.
 func NewMonad(mediaType any, value []byte, indicators ...Indicator) (*CMW, error) {
 	var c CMW
 	if err := c.val.Set(value); err != nil {
@@ -86,7 +92,9 @@ func NewMonad(mediaType any, value []byte, indicators ...Indicator) (*CMW, error
 	if err := c.typ.Set(mediaType); err != nil {
 		return nil, err
 	}
-	c.setIndicators(indicators...)
+	if err := c.setIndicators(indicators...); err != nil {
+		return nil, err
+	}
 	c.kind = KindMonad
 	return &c, nil
 }
@@ -98,10 +106,16 @@ func (o CMW) GetMonadType() (string, error) {
 	return o.monad.getType(), nil
 }
 
+// GetMonadValue returns the monad value.  The returned slice aliases the
+// value stored in the CMW.  Values backed by a reader (see NewMonadFromReader)
+// must be accessed using GetMonadValueReader instead.
 func (o CMW) GetMonadValue() ([]byte, error) {
 	if o.kind != KindMonad {
 		return nil, fmt.Errorf("want monad, got %q", o.kind)
 	}
+	if o.monad.src != nil {
+		return nil, errors.New("monad value is backed by a reader: use GetMonadValueReader")
+	}
 	return o.monad.getValue(), nil
 }
 
@@ -138,6 +152,9 @@ func (o CMW) GetCollectionType() (string, error) {
 	return o.collection.getType(), nil
 }
 
+// AddCollectionItem adds node to the collection under key, replacing any
+// existing item.  The node is copied shallowly (see CMW), so its nested maps
+// and values are shared with the collection.
 func (o *CMW) AddCollectionItem(key any, node *CMW) error {
 	if o.kind != KindCollection {
 		return fmt.Errorf("want collection, got %q", o.kind)
@@ -146,6 +163,10 @@ func (o *CMW) AddCollectionItem(key any, node *CMW) error {
 	return err
 }
 
+// GetCollectionItem returns a shallow copy of the item associated with key.
+// If the item is a collection, its map is shared with the parent, so adding
+// or replacing its items modifies the parent too.  Use Clone on the result to
+// avoid that.
 func (o CMW) GetCollectionItem(key any) (*CMW, error) {
 	if o.kind != KindCollection {
 		return nil, fmt.Errorf("want collection, got %q", o.kind)
@@ -160,13 +181,24 @@ func (o CMW) ValidateCollection() error {
 	return o.collection.validate()
 }
 
+func (o CMW) validate() error {
+	switch o.kind {
+	case KindMonad:
+		return o.monad.validate()
+	case KindCollection:
+		return o.collection.validate()
+	default:
+		return errors.New("unknown CMW kind")
+	}
+}
+
 type Meta struct {
 	Key  any
 	Kind Kind
 }
 
 // GetCollectionMeta retrieves a (sorted) list of keys and associated types in a
-// collection
+// collection.  The returned slice is not shared with the CMW.
 func (o *CMW) GetCollectionMeta() ([]Meta, error) {
 	if o.kind != KindCollection {
 		return nil, fmt.Errorf("want collection, got %q", o.kind)
@@ -174,14 +206,20 @@ func (o *CMW) GetCollectionMeta() ([]Meta, error) {
 	return o.collection.getMeta(), nil
 }
 
-func (o *CMW) setIndicators(indicators ...Indicator) {
+func (o *CMW) setIndicators(indicators ...Indicator) error {
 	var v Indicator
 
 	for _, ind := range indicators {
 		v.Set(ind)
 	}
 
+	if err := v.Validate(); err != nil {
+		return err
+	}
+
 	o.ind = v
+
+	return nil
 }
 
 func (o CMW) MarshalJSON() ([]byte, error) {
@@ -256,7 +294,24 @@ func (o *CMW) UnmarshalCBOR(b []byte) error {
 	return nil
 }
 
-func (o *CMW) Deserialize(b []byte) error {
+// Deserialize decodes the supplied buffer into the target CMW, automatically
+// detecting whether it is JSON or CBOR encoded.  By default, no envelope is
+// removed.  Base64, PEM and X.509 envelopes are removed only if enabled with
+// WithEnvelopes.  Signed, encrypted and MAC'd envelopes are removed only if
+// the corresponding verifier or decrypter is supplied via opts.  Once any
+// option is supplied, an envelope that cannot be removed is reported as an
+// error.
+func (o *CMW) Deserialize(b []byte, opts ...DeserializeOption) error {
+	var do deserializeOptions
+
+	for _, opt := range opts {
+		opt(&do)
+	}
+
+	return o.deserializeEnveloped(b, &do, 0)
+}
+
+func (o *CMW) deserializePlain(b []byte) error {
 	if len(b) == 0 {
 		return errors.New("empty buffer")
 	}
@@ -266,10 +321,13 @@ func (o *CMW) Deserialize(b []byte) error {
 	} else if startJSONRecord(s) || startJSONCollection(s) {
 		return o.UnmarshalJSON(b)
 	} else {
-		return fmt.Errorf("unknown start symbol for CMW: %c", b)
+		return fmt.Errorf("unknown start symbol for CMW: 0x%02x", s)
 	}
 }
 
+// Sniff guesses the format of a serialized CMW by looking at its first byte.
+// See SniffDetailed for a more thorough inspection that also recognises
+// enveloped CMWs.
 func Sniff(b []byte) Format {
 	if len(b) == 0 {
 		return FormatUnknown
@@ -0,0 +1,189 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package coap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxSZX is the largest block size exponent (1024-byte blocks)
+	MaxSZX = uint8(6)
+	// maxNum is the largest block number that fits the 3-byte Block option
+	maxNum = uint32(1<<20 - 1)
+)
+
+// Block is the value of a Block1 or Block2 option (RFC 7959, Section 2.2)
+type Block struct {
+	Num  uint32
+	More bool
+	SZX  uint8
+}
+
+// Size returns the block size in bytes, i.e., 2**(SZX+4)
+func (o Block) Size() int { return 1 << (o.SZX + 4) }
+
+// Offset returns the position of the block in the payload
+func (o Block) Offset() int { return int(o.Num) * o.Size() }
+
+// Encode returns the option value
+func (o Block) Encode() (uint32, error) {
+	if o.SZX > MaxSZX {
+		return 0, fmt.Errorf("SZX %d out of range", o.SZX)
+	}
+	if o.Num > maxNum {
+		return 0, fmt.Errorf("block number %d out of range", o.Num)
+	}
+
+	v := o.Num<<4 | uint32(o.SZX)
+	if o.More {
+		v |= 0x8
+	}
+
+	return v, nil
+}
+
+// ParseBlock decodes a Block1 or Block2 option value
+func ParseBlock(v uint32) (Block, error) {
+	b := Block{
+		Num:  v >> 4,
+		More: v&0x8 != 0,
+		SZX:  uint8(v & 0x7),
+	}
+
+	if b.SZX > MaxSZX {
+		return Block{}, errors.New("SZX 7 is reserved")
+	}
+
+	if b.Num > maxNum {
+		return Block{}, fmt.Errorf("block number %d out of range", b.Num)
+	}
+
+	return b, nil
+}
+
+// SZX returns the block size exponent for blockSize, which must be a power of
+// two between 16 and 1024
+func SZX(blockSize int) (uint8, error) {
+	for szx := uint8(0); szx <= MaxSZX; szx++ {
+		if 1<<(szx+4) == blockSize {
+			return szx, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid block size %d: want a power of two between 16 and 1024", blockSize)
+}
+
+// Blockwise serves a payload in blocks, e.g., a large CMW collection sent in
+// a Block2 response or a Block1 request
+type Blockwise struct {
+	payload []byte
+	szx     uint8
+}
+
+// NewBlockwise prepares payload for transfer in blocks of (at most)
+// blockSize bytes
+func NewBlockwise(payload []byte, blockSize int) (*Blockwise, error) {
+	szx, err := SZX(blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if n := (len(payload) + blockSize - 1) / blockSize; n > int(maxNum)+1 {
+		return nil, fmt.Errorf("payload too large: %d blocks of %d bytes", n, blockSize)
+	}
+
+	return &Blockwise{payload: payload, szx: szx}, nil
+}
+
+// Count returns the number of blocks at the configured block size
+func (o Blockwise) Count() int {
+	size := 1 << (o.szx + 4)
+	if len(o.payload) == 0 {
+		return 1
+	}
+	return (len(o.payload) + size - 1) / size
+}
+
+// Block returns block num at the configured size
+func (o Blockwise) Block(num uint32) ([]byte, Block, error) {
+	return o.Serve(Block{Num: num, SZX: o.szx})
+}
+
+// Serve answers a request for block req.Num, honouring the size requested by
+// the peer if it is smaller than the configured one (RFC 7959, Section 2.4).
+// The returned Block carries the size actually used, which the peer must use
+// from then on.
+func (o Blockwise) Serve(req Block) ([]byte, Block, error) {
+	b := req
+
+	if b.SZX > o.szx {
+		// use our (smaller) size: the requested block maps to a block
+		// number at our size that starts at the same offset
+		b.Num = req.Num << (req.SZX - o.szx)
+		b.SZX = o.szx
+	}
+
+	off := b.Offset()
+	if off > len(o.payload) || off == len(o.payload) && off != 0 {
+		return nil, Block{}, fmt.Errorf("block %d (size %d) beyond end of %d-byte payload", req.Num, req.Size(), len(o.payload))
+	}
+
+	end := min(off+b.Size(), len(o.payload))
+	b.More = end < len(o.payload)
+
+	return o.payload[off:end], b, nil
+}
+
+// Assembler reassembles a payload received in blocks
+type Assembler struct {
+	buf     bytes.Buffer
+	maxSize int
+	done    bool
+}
+
+// NewAssembler returns an Assembler that refuses payloads larger than maxSize
+// bytes
+func NewAssembler(maxSize int) *Assembler {
+	return &Assembler{maxSize: maxSize}
+}
+
+// Add appends a received block, which must be the next one in sequence (its
+// size may shrink across blocks).  It returns true once the last block has
+// been added.
+func (o *Assembler) Add(b Block, data []byte) (bool, error) {
+	if o.done {
+		return true, errors.New("block received after the last one")
+	}
+
+	if b.Offset() != o.buf.Len() {
+		return false, fmt.Errorf("out of sequence block %d (size %d): want offset %d, got %d", b.Num, b.Size(), o.buf.Len(), b.Offset())
+	}
+
+	if b.More && len(data) != b.Size() {
+		return false, fmt.Errorf("short block %d: want %d bytes, got %d", b.Num, b.Size(), len(data))
+	}
+
+	if len(data) > b.Size() {
+		return false, fmt.Errorf("oversized block %d: want at most %d bytes, got %d", b.Num, b.Size(), len(data))
+	}
+
+	if o.buf.Len()+len(data) > o.maxSize {
+		return false, fmt.Errorf("payload exceeds %d bytes", o.maxSize)
+	}
+
+	o.buf.Write(data)
+	o.done = !b.More
+
+	return o.done, nil
+}
+
+// Payload returns the reassembled payload once all blocks have been added
+func (o *Assembler) Payload() ([]byte, error) {
+	if !o.done {
+		return nil, errors.New("incomplete payload")
+	}
+	return o.buf.Bytes(), nil
+}
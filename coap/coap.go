@@ -0,0 +1,142 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package coap provides helpers for carrying CMWs in CoAP payloads: mapping
+// between CMW media types and CoAP Content-Format IDs, encoding and decoding
+// payloads, and splitting/reassembling large payloads using Block-wise
+// transfers (RFC 7959).  It does not depend on any particular CoAP stack.
+package coap
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+
+	"github.com/veraison/cmw"
+)
+
+const (
+	MediaTypeCMWCBOR = "application/cmw+cbor"
+	MediaTypeCMWJSON = "application/cmw+json"
+	MediaTypeCMWCOSE = "application/cmw+cose"
+	MediaTypeCMWJWS  = "application/cmw+jws"
+)
+
+// Content-Format IDs of the CMW media types.  IANA has not assigned them yet
+// (see the IANA considerations of draft-ietf-rats-msg-wrap), so, until it
+// does, IDs from the Experimental Use range [65000, 65535] are used.  Record
+// and Collection CMWs share the same Content-Format: Content-Formats cannot
+// carry the optional cmwc_t media type parameter.
+const (
+	ContentFormatCMWCBOR = uint16(65000)
+	ContentFormatCMWJSON = uint16(65001)
+	ContentFormatCMWCOSE = uint16(65002)
+	ContentFormatCMWJWS  = uint16(65003)
+)
+
+var cf2mt = map[uint16]string{
+	ContentFormatCMWCBOR: MediaTypeCMWCBOR,
+	ContentFormatCMWJSON: MediaTypeCMWJSON,
+	ContentFormatCMWCOSE: MediaTypeCMWCOSE,
+	ContentFormatCMWJWS:  MediaTypeCMWJWS,
+}
+
+// MediaType returns the CMW media type associated with the Content-Format cf
+func MediaType(cf uint16) (string, error) {
+	mt, ok := cf2mt[cf]
+	if !ok {
+		return "", fmt.Errorf("not a CMW Content-Format: %d", cf)
+	}
+	return mt, nil
+}
+
+// ContentFormat returns the Content-Format associated with the CMW media type
+// mt.  Media type parameters (e.g., cmwc_t) are ignored.
+func ContentFormat(mt string) (uint16, error) {
+	base, _, err := mime.ParseMediaType(mt)
+	if err != nil {
+		return 0, fmt.Errorf("parsing media type: %w", err)
+	}
+
+	for cf, v := range cf2mt {
+		if v == base {
+			return cf, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%q is not a CMW media type", mt)
+}
+
+// ContentFormatOf returns the Content-Format describing the serialized CMW b,
+// i.e., that of the signed variants for signed-cbor-cmw and signed-json-cmw,
+// and that of the plain CBOR or JSON serialization otherwise
+func ContentFormatOf(b []byte) (uint16, error) {
+	sr := cmw.SniffDetailed(b)
+
+	switch sr.Envelope {
+	case cmw.EnvelopeSignedCBOR:
+		return ContentFormatCMWCOSE, nil
+	case cmw.EnvelopeSignedJSON:
+		return ContentFormatCMWJWS, nil
+	case cmw.EnvelopeNone:
+	default:
+		return 0, fmt.Errorf("no Content-Format for CMWs in a %s envelope", sr.Envelope)
+	}
+
+	switch sr.Format {
+	case cmw.FormatCBORRecord, cmw.FormatCBORCollection, cmw.FormatCBORTag:
+		return ContentFormatCMWCBOR, nil
+	case cmw.FormatJSONRecord, cmw.FormatJSONCollection:
+		return ContentFormatCMWJSON, nil
+	default:
+		return 0, errors.New("not a CMW")
+	}
+}
+
+// Encode serializes c as a CoAP payload with the Content-Format cf, which
+// must be either ContentFormatCMWCBOR or ContentFormatCMWJSON.  (Use
+// CMW.SignCBOR or CMW.SignJSON to produce the signed variants.)
+func Encode(c *cmw.CMW, cf uint16) ([]byte, error) {
+	if c == nil {
+		return nil, errors.New("nil CMW")
+	}
+
+	switch cf {
+	case ContentFormatCMWCBOR:
+		return c.MarshalCBOR()
+	case ContentFormatCMWJSON:
+		return c.MarshalJSON()
+	default:
+		return nil, fmt.Errorf("cannot encode to Content-Format %d: want %d or %d", cf, ContentFormatCMWCBOR, ContentFormatCMWJSON)
+	}
+}
+
+// Decode decodes a CoAP payload with Content-Format cf.  Signed variants are
+// verified using the verifier supplied via opts (see cmw.WithCOSEVerifier and
+// cmw.WithJWSVerifier).
+func Decode(payload []byte, cf uint16, opts ...cmw.DeserializeOption) (*cmw.CMW, error) {
+	var (
+		c   cmw.CMW
+		err error
+	)
+
+	switch cf {
+	case ContentFormatCMWCBOR:
+		err = c.UnmarshalCBOR(payload)
+	case ContentFormatCMWJSON:
+		err = c.UnmarshalJSON(payload)
+	case ContentFormatCMWCOSE, ContentFormatCMWJWS:
+		if got, _ := ContentFormatOf(payload); got != cf {
+			return nil, fmt.Errorf("payload does not match Content-Format %d", cf)
+		}
+		err = c.Deserialize(payload, opts...)
+	default:
+		return nil, fmt.Errorf("not a CMW Content-Format: %d", cf)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
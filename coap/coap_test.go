@@ -0,0 +1,222 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package coap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/cmw"
+	cose "github.com/veraison/go-cose"
+)
+
+func makeLargeCollection(t *testing.T, n int) *cmw.CMW {
+	c, err := cmw.NewCollection("tag:example.com,2025:large")
+	require.NoError(t, err)
+
+	for i := range n {
+		m, err := cmw.NewMonad("application/eat+cwt", []byte(fmt.Sprintf("evidence #%d", i)), cmw.Evidence)
+		require.NoError(t, err)
+		require.NoError(t, c.AddCollectionItem(fmt.Sprintf("attester-%d", i), m))
+	}
+
+	return c
+}
+
+func Test_MediaType_ContentFormat(t *testing.T) {
+	for cf, mt := range map[uint16]string{
+		ContentFormatCMWCBOR: MediaTypeCMWCBOR,
+		ContentFormatCMWJSON: MediaTypeCMWJSON,
+		ContentFormatCMWCOSE: MediaTypeCMWCOSE,
+		ContentFormatCMWJWS:  MediaTypeCMWJWS,
+	} {
+		actualMT, err := MediaType(cf)
+		require.NoError(t, err)
+		assert.Equal(t, mt, actualMT)
+
+		actualCF, err := ContentFormat(mt)
+		require.NoError(t, err)
+		assert.Equal(t, cf, actualCF)
+	}
+
+	cf, err := ContentFormat(`application/cmw+cbor; cmwc_t="tag:example.com,2025:x"`)
+	require.NoError(t, err)
+	assert.Equal(t, ContentFormatCMWCBOR, cf)
+
+	_, err = MediaType(60)
+	assert.EqualError(t, err, "not a CMW Content-Format: 60")
+
+	_, err = ContentFormat("application/cbor")
+	assert.EqualError(t, err, `"application/cbor" is not a CMW media type`)
+}
+
+func Test_ContentFormatOf(t *testing.T) {
+	c := makeLargeCollection(t, 2)
+
+	cb, err := c.MarshalCBOR()
+	require.NoError(t, err)
+	jb, err := c.MarshalJSON()
+	require.NoError(t, err)
+
+	signer, err := cose.NewSigner(cose.AlgorithmES256, mustSigningKey(t))
+	require.NoError(t, err)
+	sb, err := c.SignCBOR(signer)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		payload  []byte
+		expected uint16
+	}{
+		{"CBOR", cb, ContentFormatCMWCBOR},
+		{"JSON", jb, ContentFormatCMWJSON},
+		{"COSE", sb, ContentFormatCMWCOSE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cf, err := ContentFormatOf(tt.payload)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cf)
+		})
+	}
+
+	_, err = ContentFormatOf([]byte("hello"))
+	assert.EqualError(t, err, "not a CMW")
+}
+
+func Test_Encode_Decode(t *testing.T) {
+	c := makeLargeCollection(t, 3)
+
+	for _, cf := range []uint16{ContentFormatCMWCBOR, ContentFormatCMWJSON} {
+		payload, err := Encode(c, cf)
+		require.NoError(t, err)
+
+		actual, err := Decode(payload, cf)
+		require.NoError(t, err)
+		assert.Equal(t, cmw.KindCollection, actual.GetKind())
+
+		meta, err := actual.GetCollectionMeta()
+		require.NoError(t, err)
+		assert.Len(t, meta, 3)
+	}
+
+	_, err := Encode(c, ContentFormatCMWCOSE)
+	assert.EqualError(t, err, "cannot encode to Content-Format 65002: want 65000 or 65001")
+
+	_, err = Decode([]byte{0xa0}, 60)
+	assert.EqualError(t, err, "not a CMW Content-Format: 60")
+
+	_, err = Decode([]byte{0x82}, ContentFormatCMWCOSE)
+	assert.EqualError(t, err, "payload does not match Content-Format 65002")
+}
+
+func Test_Decode_signed(t *testing.T) {
+	c := makeLargeCollection(t, 2)
+
+	key := mustSigningKey(t)
+
+	signer, err := cose.NewSigner(cose.AlgorithmES256, key)
+	require.NoError(t, err)
+	payload, err := c.SignCBOR(signer)
+	require.NoError(t, err)
+
+	verifier, err := cose.NewVerifier(cose.AlgorithmES256, key.Public())
+	require.NoError(t, err)
+
+	actual, err := Decode(payload, ContentFormatCMWCOSE, cmw.WithCOSEVerifier(verifier))
+	require.NoError(t, err)
+	assert.Equal(t, cmw.KindCollection, actual.GetKind())
+
+	_, err = Decode(payload, ContentFormatCMWCOSE)
+	assert.Error(t, err)
+}
+
+func Test_Block_Encode_Parse(t *testing.T) {
+	tests := []struct {
+		b Block
+		v uint32
+	}{
+		{Block{Num: 0, More: true, SZX: 6}, 0x0e},
+		{Block{Num: 1, More: false, SZX: 2}, 0x12},
+		{Block{Num: 1<<20 - 1, More: true, SZX: 0}, 0xfffff8},
+	}
+
+	for _, tt := range tests {
+		v, err := tt.b.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, tt.v, v)
+
+		b, err := ParseBlock(tt.v)
+		require.NoError(t, err)
+		assert.Equal(t, tt.b, b)
+	}
+
+	_, err := ParseBlock(0x07)
+	assert.EqualError(t, err, "SZX 7 is reserved")
+
+	_, err = Block{SZX: 7}.Encode()
+	assert.EqualError(t, err, "SZX 7 out of range")
+
+	_, err = SZX(100)
+	assert.EqualError(t, err, "invalid block size 100: want a power of two between 16 and 1024")
+}
+
+func Test_Blockwise_Serve_smaller_size(t *testing.T) {
+	payload := make([]byte, 100)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+
+	bw, err := NewBlockwise(payload, 32)
+	require.NoError(t, err)
+	assert.Equal(t, 4, bw.Count())
+
+	// the peer asks for 64-byte blocks: block 1 at size 64 is block 2 at
+	// size 32
+	data, b, err := bw.Serve(Block{Num: 1, SZX: 2})
+	require.NoError(t, err)
+	assert.Equal(t, Block{Num: 2, More: true, SZX: 1}, b)
+	assert.Equal(t, payload[64:96], data)
+
+	// the peer asks for 16-byte blocks, which we honour
+	data, b, err = bw.Serve(Block{Num: 6, SZX: 0})
+	require.NoError(t, err)
+	assert.Equal(t, Block{Num: 6, More: false, SZX: 0}, b)
+	assert.Equal(t, payload[96:], data)
+
+	_, _, err = bw.Block(4)
+	assert.EqualError(t, err, "block 4 (size 32) beyond end of 100-byte payload")
+}
+
+func Test_Assembler_fail(t *testing.T) {
+	a := NewAssembler(40)
+
+	_, err := a.Add(Block{Num: 1, More: true, SZX: 0}, make([]byte, 16))
+	assert.EqualError(t, err, "out of sequence block 1 (size 16): want offset 0, got 16")
+
+	_, err = a.Add(Block{Num: 0, More: true, SZX: 0}, make([]byte, 15))
+	assert.EqualError(t, err, "short block 0: want 16 bytes, got 15")
+
+	_, err = a.Add(Block{Num: 0, More: false, SZX: 0}, make([]byte, 17))
+	assert.EqualError(t, err, "oversized block 0: want at most 16 bytes, got 17")
+
+	_, err = a.Payload()
+	assert.EqualError(t, err, "incomplete payload")
+
+	_, err = a.Add(Block{Num: 0, More: true, SZX: 1}, make([]byte, 32))
+	require.NoError(t, err)
+
+	_, err = a.Add(Block{Num: 2, More: true, SZX: 0}, make([]byte, 16))
+	assert.EqualError(t, err, "payload exceeds 40 bytes")
+
+	done, err := a.Add(Block{Num: 2, More: false, SZX: 0}, make([]byte, 8))
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	_, err = a.Add(Block{Num: 3, More: false, SZX: 0}, nil)
+	assert.EqualError(t, err, "block received after the last one")
+}
@@ -0,0 +1,240 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package coap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/cmw"
+)
+
+func mustSigningKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+// testMessage is a stand-in for a CoAP message that only carries what
+// matters to CMW transfers
+type testMessage struct {
+	Code          string
+	ContentFormat uint16
+	Block1        *uint32
+	Block2        *uint32
+	Payload       []byte
+}
+
+func optionValue(t *testing.T, b Block) *uint32 {
+	v, err := b.Encode()
+	require.NoError(t, err)
+	return &v
+}
+
+// testServer is an in-process stand-in for a CoAP server exposing a single
+// CMW resource that can be fetched (GET, Block2) and replaced (PUT, Block1)
+type testServer struct {
+	t         *testing.T
+	blockSize int
+	resource  []byte
+	cf        uint16
+	upload    *Assembler
+}
+
+func (s *testServer) handle(req testMessage) testMessage {
+	switch req.Code {
+	case "GET":
+		bw, err := NewBlockwise(s.resource, s.blockSize)
+		require.NoError(s.t, err)
+
+		want := Block{SZX: MaxSZX}
+		if req.Block2 != nil {
+			if want, err = ParseBlock(*req.Block2); err != nil {
+				return testMessage{Code: "4.02"}
+			}
+		}
+
+		data, b, err := bw.Serve(want)
+		if err != nil {
+			return testMessage{Code: "4.02"}
+		}
+
+		return testMessage{Code: "2.05", ContentFormat: s.cf, Block2: optionValue(s.t, b), Payload: data}
+	case "PUT":
+		if _, err := MediaType(req.ContentFormat); err != nil {
+			return testMessage{Code: "4.15"}
+		}
+
+		if req.Block1 == nil {
+			return s.replace(req.Payload, req.ContentFormat)
+		}
+
+		b, err := ParseBlock(*req.Block1)
+		if err != nil {
+			return testMessage{Code: "4.02"}
+		}
+
+		if b.Num == 0 {
+			s.upload = NewAssembler(4096)
+		}
+
+		if s.upload == nil {
+			return testMessage{Code: "4.08"}
+		}
+
+		done, err := s.upload.Add(b, req.Payload)
+		if err != nil {
+			s.upload = nil
+			return testMessage{Code: "4.08"}
+		}
+
+		if !done {
+			return testMessage{Code: "2.31", Block1: req.Block1}
+		}
+
+		payload, _ := s.upload.Payload()
+		s.upload = nil
+
+		res := s.replace(payload, req.ContentFormat)
+		res.Block1 = req.Block1
+
+		return res
+	default:
+		return testMessage{Code: "4.05"}
+	}
+}
+
+func (s *testServer) replace(payload []byte, cf uint16) testMessage {
+	if _, err := Decode(payload, cf); err != nil {
+		return testMessage{Code: "4.00"}
+	}
+
+	s.resource, s.cf = payload, cf
+
+	return testMessage{Code: "2.04"}
+}
+
+// fetch retrieves the resource with Block2, asking for blocks of blockSize
+func fetch(t *testing.T, s *testServer, blockSize int) (*cmw.CMW, int) {
+	szx, err := SZX(blockSize)
+	require.NoError(t, err)
+
+	a := NewAssembler(1 << 16)
+	next := Block{SZX: szx}
+	exchanges := 0
+
+	for {
+		res := s.handle(testMessage{Code: "GET", Block2: optionValue(t, next)})
+		exchanges++
+		require.Equal(t, "2.05", res.Code)
+		require.NotNil(t, res.Block2)
+
+		b, err := ParseBlock(*res.Block2)
+		require.NoError(t, err)
+
+		done, err := a.Add(b, res.Payload)
+		require.NoError(t, err)
+
+		if done {
+			payload, err := a.Payload()
+			require.NoError(t, err)
+			c, err := Decode(payload, res.ContentFormat)
+			require.NoError(t, err)
+			return c, exchanges
+		}
+
+		// continue with the size chosen by the server
+		next = Block{Num: b.Num + 1, SZX: b.SZX}
+	}
+}
+
+// store replaces the resource with c, using Block1 with blocks of blockSize
+func store(t *testing.T, s *testServer, c *cmw.CMW, cf uint16, blockSize int) (string, int) {
+	payload, err := Encode(c, cf)
+	require.NoError(t, err)
+
+	bw, err := NewBlockwise(payload, blockSize)
+	require.NoError(t, err)
+
+	var res testMessage
+
+	for num := 0; num < bw.Count(); num++ {
+		data, b, err := bw.Block(uint32(num))
+		require.NoError(t, err)
+
+		res = s.handle(testMessage{Code: "PUT", ContentFormat: cf, Block1: optionValue(t, b), Payload: data})
+		if res.Code != "2.31" {
+			break
+		}
+	}
+
+	return res.Code, bw.Count()
+}
+
+func Test_standin_fetch_large_collection(t *testing.T) {
+	c := makeLargeCollection(t, 50)
+
+	payload, err := Encode(c, ContentFormatCMWCBOR)
+	require.NoError(t, err)
+	require.Greater(t, len(payload), 1024)
+
+	s := &testServer{t: t, blockSize: 256, resource: payload, cf: ContentFormatCMWCBOR}
+
+	for _, blockSize := range []int{16, 64, 1024} {
+		actual, exchanges := fetch(t, s, blockSize)
+
+		// the server never uses blocks larger than its own
+		effective := min(blockSize, 256)
+		assert.Equal(t, (len(payload)+effective-1)/effective, exchanges, blockSize)
+
+		b, err := actual.MarshalCBOR()
+		require.NoError(t, err)
+		assert.Equal(t, payload, b)
+	}
+}
+
+func Test_standin_store_large_collection(t *testing.T) {
+	c := makeLargeCollection(t, 50)
+
+	s := &testServer{t: t, blockSize: 1024}
+
+	code, blocks := store(t, s, c, ContentFormatCMWJSON, 128)
+	assert.Equal(t, "2.04", code)
+	assert.Greater(t, blocks, 1)
+
+	actual, _ := fetch(t, s, 1024)
+	assert.Equal(t, cmw.FormatJSONCollection, actual.GetFormat())
+
+	meta, err := actual.GetCollectionMeta()
+	require.NoError(t, err)
+	assert.Len(t, meta, 50)
+}
+
+func Test_standin_store_failures(t *testing.T) {
+	c := makeLargeCollection(t, 1)
+	s := &testServer{t: t, blockSize: 1024}
+
+	// unsupported Content-Format
+	res := s.handle(testMessage{Code: "PUT", ContentFormat: 60, Payload: []byte{0xa0}})
+	assert.Equal(t, "4.15", res.Code)
+
+	// continuation without the first block
+	res = s.handle(testMessage{Code: "PUT", ContentFormat: ContentFormatCMWCBOR, Block1: optionValue(t, Block{Num: 1, SZX: 0})})
+	assert.Equal(t, "4.08", res.Code)
+
+	// too large
+	big := makeLargeCollection(t, 200)
+	code, _ := store(t, s, big, ContentFormatCMWCBOR, 1024)
+	assert.Equal(t, "4.08", code)
+
+	// single exchange, no Block1
+	payload, err := Encode(c, ContentFormatCMWCBOR)
+	require.NoError(t, err)
+	res = s.handle(testMessage{Code: "PUT", ContentFormat: ContentFormatCMWCBOR, Payload: payload})
+	assert.Equal(t, "2.04", res.Code)
+}
@@ -35,7 +35,7 @@ func (o collection) validate() error {
 		}
 	}
 
-	return nil
+	return o.checkProfile()
 }
 
 func validateCollectionKey(key any) error {
@@ -108,7 +108,7 @@ func (o Meta) getKeyForSorting() string {
 	switch t := o.Key.(type) {
 	case string:
 		return t
-	case uint64:
+	case uint64, int64:
 		return fmt.Sprintf("##%d", t)
 	default:
 		panic(fmt.Sprintf("key with unknown type %T", t))
@@ -192,6 +192,19 @@ func (o *collection) UnmarshalCBOR(b []byte) error {
 		return fmt.Errorf("unmarshaling CBOR collection: %w", err)
 	}
 
+	if err := o.fromCBORMap(tmp); err != nil {
+		return err
+	}
+
+	if err := o.checkProfile(); err != nil {
+		return fmt.Errorf("checking CBOR collection profile: %w", err)
+	}
+
+	return nil
+}
+
+// fromCBORMap populates the collection from a decoded CBOR map
+func (o *collection) fromCBORMap(tmp map[any]cbor.RawMessage) error {
 	// extract CMW collection type
 	cmwcT, found := tmp[CmwCType]
 	if found {
@@ -211,6 +224,10 @@ func (o *collection) UnmarshalCBOR(b []byte) error {
 	for k, v := range tmp {
 		var c CMW
 
+		if err := validateCollectionKey(k); err != nil {
+			return fmt.Errorf("checking CBOR collection key: %w", err)
+		}
+
 		start := v[0]
 
 		switch {
@@ -264,6 +281,10 @@ func (o *collection) UnmarshalJSON(b []byte) error {
 	for k, v := range tmp {
 		var c CMW
 
+		if err := validateCollectionKey(k); err != nil {
+			return fmt.Errorf("checking JSON collection key: %w", err)
+		}
+
 		start := v[0]
 
 		switch {
@@ -287,5 +308,9 @@ func (o *collection) UnmarshalJSON(b []byte) error {
 	o.cmap = m
 	o.format = FormatJSONCollection
 
+	if err := o.checkProfile(); err != nil {
+		return fmt.Errorf("checking JSON collection profile: %w", err)
+	}
+
 	return nil
 }
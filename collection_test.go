@@ -83,6 +83,42 @@ func Test_Collection_JSON_Deserialize_fail_inner(t *testing.T) {
 	assert.EqualError(t, err, `invalid collection at key "a": empty CMW collection`)
 }
 
+func Test_Collection_Deserialize_fail_bad_key(t *testing.T) {
+	tests := []struct {
+		name     string
+		tv       []byte
+		expected string
+	}{
+		{
+			"CBOR tag key",
+			mustHexDecode("a2d466737472696e67820041ff1904ceda6374010141d3"),
+			"checking CBOR collection key: unknown collection key type: want string or int, got cbor.Tag",
+		},
+		{
+			"CBOR float key",
+			mustHexDecode("a1f93c00820041ff"),
+			"checking CBOR collection key: unknown collection key type: want string or int, got float64",
+		},
+		{
+			"CBOR byte string key",
+			mustHexDecode("a141ff820041ff"),
+			"checking CBOR collection key: unknown collection key type: want string or int, got cbor.ByteString",
+		},
+		{
+			"JSON whitespace key",
+			[]byte(`{ " ": ["application/vnd.a", "YQ"] }`),
+			"checking JSON collection key: bad collection key: empty or whitespace only",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actual CMW
+			assert.EqualError(t, actual.Deserialize(tt.tv), tt.expected)
+		})
+	}
+}
+
 func Test_Collection_CBOR_Deserialize_ok(t *testing.T) {
 	tv := mustReadFile(t, "testdata/collection-cbor-ok.cbor")
 
@@ -255,3 +291,20 @@ func Test_NewCollection_fail_bad_cmwc_t(t *testing.T) {
 	_, err := NewCollection("1.2 3.4")
 	assert.EqualError(t, err, `invalid collection type: "1.2 3.4".  URI is not absolute`)
 }
+
+func Test_Collection_Validate_ok(t *testing.T) {
+	tv := makeCMWCollection()
+
+	err := tv.ValidateCollection()
+	assert.NoError(t, err)
+}
+
+func Test_Collection_Validate_fail_monad(t *testing.T) {
+	tv, err := NewCollection("")
+	require.NoError(t, err)
+
+	require.NoError(t, tv.AddCollectionItem("a", &CMW{kind: KindMonad}))
+
+	err = tv.ValidateCollection()
+	assert.EqualError(t, err, `invalid collection at key "a": type and value MUST be set in CMW`)
+}
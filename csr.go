@@ -0,0 +1,345 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// OidAttrEvidence is the id-aa-evidence CSR attribute defined in
+// draft-ietf-lamps-csr-attestation.  CMWs are carried in EvidenceStatements
+// whose type is id-pe-cmw.
+var OidAttrEvidence = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 59}
+
+// CSRSource identifies where in a CSR a CMW was found
+type CSRSource uint
+
+const (
+	// extensionRequest attribute (RFC 2985) carrying an id-pe-cmw extension
+	CSRSourceExtension = CSRSource(iota)
+	// id-aa-evidence attribute carrying an id-pe-cmw EvidenceStatement
+	CSRSourceEvidenceAttribute
+)
+
+func (o CSRSource) String() string {
+	switch o {
+	case CSRSourceExtension:
+		return "extension request"
+	case CSRSourceEvidenceAttribute:
+		return "evidence attribute"
+	default:
+		return "unknown"
+	}
+}
+
+// CSRItem is a CMW found in a CSR.  Index is the position of the CMW among
+// the ones with the same Source, in order of appearance.  Critical is only
+// meaningful for CSRSourceExtension.
+type CSRItem struct {
+	CMW      *CMW
+	Source   CSRSource
+	Index    int
+	Critical bool
+}
+
+// AddToCertificateRequest wraps the target CMW in an id-pe-cmw extension (see
+// EncodeX509Extension) and appends it to the ExtraExtensions of the supplied
+// CSR template, which results in the CMW being carried in the extensionRequest
+// attribute by x509.CreateCertificateRequest.  Since a CSR cannot request the
+// same extension twice, only one CMW can be added this way: use
+// CreateCertificateRequestWithEvidence to convey more.
+func (o CMW) AddToCertificateRequest(tmpl *x509.CertificateRequest, choice Choice) error {
+	if tmpl == nil {
+		return errors.New("nil CSR template")
+	}
+
+	for _, extn := range slices.Concat(tmpl.Extensions, tmpl.ExtraExtensions) {
+		if extn.Id.Equal(OidExtCmw) {
+			return errors.New("CSR template already has an id-pe-cmw extension")
+		}
+	}
+
+	extn, err := o.EncodeX509Extension(choice, false)
+	if err != nil {
+		return err
+	}
+
+	tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, *extn)
+
+	return nil
+}
+
+// CreateCertificateRequestWithEvidence is like x509.CreateCertificateRequest,
+// except that the supplied CMWs are also carried in an id-aa-evidence attribute
+// as id-pe-cmw EvidenceStatements (one per CMW, in a single EvidenceBundle).
+// Each CMW is serialized according to choice.
+func CreateCertificateRequestWithEvidence(
+	rand io.Reader,
+	tmpl *x509.CertificateRequest,
+	priv crypto.Signer,
+	choice Choice,
+	cmws ...*CMW,
+) ([]byte, error) {
+	if len(cmws) == 0 {
+		return nil, errors.New("no CMW supplied")
+	}
+
+	attr, err := encodeEvidenceAttribute(choice, cmws)
+	if err != nil {
+		return nil, err
+	}
+
+	// let the standard library do the heavy lifting (subject, extensions,
+	// signature algorithm selection, etc.), then splice in the evidence
+	// attribute and re-sign
+	der, err := x509.CreateCertificateRequest(rand, tmpl, priv)
+	if err != nil {
+		return nil, fmt.Errorf("creating CSR: %w", err)
+	}
+
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CSR: %w", err)
+	}
+
+	var outer certificateRequest
+	if _, err := asn1.Unmarshal(der, &outer); err != nil {
+		return nil, fmt.Errorf("decoding CSR: %w", err)
+	}
+
+	var tbs tbsCertificateRequest
+	if _, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+		return nil, fmt.Errorf("decoding CSR info: %w", err)
+	}
+
+	tbs.Raw = nil
+	tbs.RawAttributes = append(tbs.RawAttributes, attr)
+
+	tbsDER, err := asn1.Marshal(tbs)
+	if err != nil {
+		return nil, fmt.Errorf("encoding CSR info: %w", err)
+	}
+
+	sig, err := signTBS(rand, priv, csr.SignatureAlgorithm, tbsDER)
+	if err != nil {
+		return nil, fmt.Errorf("signing CSR: %w", err)
+	}
+
+	return asn1.Marshal(certificateRequest{
+		TBSCSR:             asn1.RawValue{FullBytes: tbsDER},
+		SignatureAlgorithm: outer.SignatureAlgorithm,
+		SignatureValue:     asn1.BitString{Bytes: sig, BitLength: len(sig) * 8},
+	})
+}
+
+// DecodeCertificateRequest extracts and decodes all the CMWs found in the
+// supplied CSR, both in id-pe-cmw extensions (extensionRequest attribute) and
+// in id-pe-cmw EvidenceStatements (id-aa-evidence attribute).  Extension items
+// are returned first, followed by evidence attribute items, each in order of
+// appearance.  The CSR signature is not checked: use CheckSignature for that.
+func DecodeCertificateRequest(csr *x509.CertificateRequest) ([]CSRItem, error) {
+	if csr == nil {
+		return nil, errors.New("nil CSR")
+	}
+
+	var items []CSRItem
+
+	for _, extn := range csr.Extensions {
+		if !extn.Id.Equal(OidExtCmw) {
+			continue
+		}
+
+		idx := len(items)
+
+		c, err := DecodeX509Extension(extn)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", CSRSourceExtension, idx, err)
+		}
+
+		items = append(items, CSRItem{
+			CMW:      c,
+			Source:   CSRSourceExtension,
+			Index:    idx,
+			Critical: extn.Critical,
+		})
+	}
+
+	stmts, err := parseEvidenceStatements(csr.RawTBSCertificateRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, stmt := range stmts {
+		c, err := DecodeX509Extension(pkix.Extension{Id: OidExtCmw, Value: stmt.Stmt.FullBytes})
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", CSRSourceEvidenceAttribute, i, err)
+		}
+
+		items = append(items, CSRItem{
+			CMW:    c,
+			Source: CSRSourceEvidenceAttribute,
+			Index:  i,
+		})
+	}
+
+	return items, nil
+}
+
+type certificateRequest struct {
+	TBSCSR             asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+type tbsCertificateRequest struct {
+	Raw           asn1.RawContent
+	Version       int
+	Subject       asn1.RawValue
+	PublicKey     asn1.RawValue
+	RawAttributes []asn1.RawValue `asn1:"tag:0"`
+}
+
+type csrAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+//	EvidenceStatement ::= SEQUENCE {
+//	  type   EVIDENCE-STATEMENT.&id({EvidenceStatementSet}),
+//	  stmt   EVIDENCE-STATEMENT.&Type({EvidenceStatementSet}{@type}),
+//	  hint   UTF8String OPTIONAL
+//	}
+type evidenceStatement struct {
+	Type asn1.ObjectIdentifier
+	Stmt asn1.RawValue
+	Hint string `asn1:"optional,utf8"`
+}
+
+//	EvidenceBundle ::= SEQUENCE {
+//	  evidences SEQUENCE SIZE (1..MAX) OF EvidenceStatement,
+//	  certs SEQUENCE SIZE (1..MAX) OF CertificateChoices OPTIONAL
+//	}
+type evidenceBundle struct {
+	Evidences []evidenceStatement
+	Certs     []asn1.RawValue `asn1:"optional"`
+}
+
+func encodeEvidenceAttribute(choice Choice, cmws []*CMW) (asn1.RawValue, error) {
+	var bundle evidenceBundle
+
+	for i, c := range cmws {
+		if c == nil {
+			return asn1.RawValue{}, fmt.Errorf("CMW %d: nil CMW", i)
+		}
+
+		extn, err := c.EncodeX509Extension(choice, false)
+		if err != nil {
+			return asn1.RawValue{}, fmt.Errorf("CMW %d: %w", i, err)
+		}
+
+		bundle.Evidences = append(bundle.Evidences, evidenceStatement{
+			Type: OidExtCmw,
+			Stmt: asn1.RawValue{FullBytes: extn.Value},
+		})
+	}
+
+	// EvidenceBundles ::= SEQUENCE SIZE (1..MAX) OF EvidenceBundle
+	bundles, err := asn1.Marshal([]evidenceBundle{bundle})
+	if err != nil {
+		return asn1.RawValue{}, fmt.Errorf("encoding EvidenceBundles: %w", err)
+	}
+
+	attr, err := asn1.Marshal(csrAttribute{
+		Type:   OidAttrEvidence,
+		Values: []asn1.RawValue{{FullBytes: bundles}},
+	})
+	if err != nil {
+		return asn1.RawValue{}, fmt.Errorf("encoding evidence attribute: %w", err)
+	}
+
+	return asn1.RawValue{FullBytes: attr}, nil
+}
+
+// parseEvidenceStatements returns the id-pe-cmw EvidenceStatements found in
+// all the id-aa-evidence attributes of the supplied CertificationRequestInfo
+func parseEvidenceStatements(rawTBS []byte) ([]evidenceStatement, error) {
+	var tbs tbsCertificateRequest
+	if _, err := asn1.Unmarshal(rawTBS, &tbs); err != nil {
+		return nil, fmt.Errorf("decoding CSR info: %w", err)
+	}
+
+	var stmts []evidenceStatement
+
+	for _, rawAttr := range tbs.RawAttributes {
+		var attr csrAttribute
+		if _, err := asn1.Unmarshal(rawAttr.FullBytes, &attr); err != nil {
+			return nil, fmt.Errorf("decoding CSR attribute: %w", err)
+		}
+
+		if !attr.Type.Equal(OidAttrEvidence) {
+			continue
+		}
+
+		for _, v := range attr.Values {
+			var bundles []evidenceBundle
+			if _, err := asn1.Unmarshal(v.FullBytes, &bundles); err != nil {
+				return nil, fmt.Errorf("decoding EvidenceBundles: %w", err)
+			}
+
+			for _, bundle := range bundles {
+				for _, stmt := range bundle.Evidences {
+					if stmt.Type.Equal(OidExtCmw) {
+						stmts = append(stmts, stmt)
+					}
+				}
+			}
+		}
+	}
+
+	return stmts, nil
+}
+
+func signTBS(rand io.Reader, priv crypto.Signer, alg x509.SignatureAlgorithm, tbs []byte) ([]byte, error) {
+	var (
+		h      crypto.Hash
+		signed = tbs
+		opts   crypto.SignerOpts
+	)
+
+	switch alg {
+	case x509.SHA256WithRSA, x509.ECDSAWithSHA256, x509.SHA256WithRSAPSS:
+		h = crypto.SHA256
+	case x509.SHA384WithRSA, x509.ECDSAWithSHA384, x509.SHA384WithRSAPSS:
+		h = crypto.SHA384
+	case x509.SHA512WithRSA, x509.ECDSAWithSHA512, x509.SHA512WithRSAPSS:
+		h = crypto.SHA512
+	case x509.PureEd25519:
+		// Ed25519 signs the message itself
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm %s", alg)
+	}
+
+	opts = h
+
+	if h != 0 {
+		d := h.New()
+		d.Write(tbs)
+		signed = d.Sum(nil)
+	}
+
+	switch alg {
+	case x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS:
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: h}
+	}
+
+	return priv.Sign(rand, signed, opts)
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeCSRTemplate() *x509.CertificateRequest {
+	return &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "attester.example"},
+	}
+}
+
+func TestCMW_AddToCertificateRequest_roundtrip(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for _, choice := range []Choice{ChoiceJson, ChoiceCbor} {
+		tmpl := makeCSRTemplate()
+		require.NoError(t, makeCMWCollection().AddToCertificateRequest(tmpl, choice))
+
+		der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+		require.NoError(t, err)
+
+		csr, err := x509.ParseCertificateRequest(der)
+		require.NoError(t, err)
+
+		items, err := DecodeCertificateRequest(csr)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+
+		assert.Equal(t, CSRSourceExtension, items[0].Source)
+		assert.Equal(t, 0, items[0].Index)
+		assert.False(t, items[0].Critical)
+		assert.Equal(t, KindCollection, items[0].CMW.GetKind())
+	}
+}
+
+func TestCMW_AddToCertificateRequest_ko(t *testing.T) {
+	m, err := NewMonad("application/eat-ucs+cbor", []byte{0xa1, 0x0a}, Evidence)
+	require.NoError(t, err)
+
+	err = m.AddToCertificateRequest(nil, ChoiceCbor)
+	assert.EqualError(t, err, "nil CSR template")
+
+	tmpl := makeCSRTemplate()
+	require.NoError(t, m.AddToCertificateRequest(tmpl, ChoiceCbor))
+
+	err = m.AddToCertificateRequest(tmpl, ChoiceJson)
+	assert.EqualError(t, err, "CSR template already has an id-pe-cmw extension")
+}
+
+func TestCMW_AddToCertificateRequest_does_not_alias_extensions(t *testing.T) {
+	m, err := NewMonad("application/eat-ucs+cbor", []byte{0xa1, 0x0a}, Evidence)
+	require.NoError(t, err)
+
+	// Extensions has spare capacity shared with another slice
+	backing := make([]pkix.Extension, 1, 4)
+	backing[0] = pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3}}
+	spare := backing[:2]
+
+	tmpl := makeCSRTemplate()
+	tmpl.Extensions = backing[:1]
+	tmpl.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 2, 4}}}
+
+	require.NoError(t, m.AddToCertificateRequest(tmpl, ChoiceCbor))
+	assert.Equal(t, pkix.Extension{}, spare[1])
+}
+
+func TestCreateCertificateRequestWithEvidence(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, key := range []crypto.Signer{ecKey, edKey} {
+		c := makeCMWCollection()
+		m, err := NewMonad("application/eat-ucs+cbor", []byte{0xa1, 0x0a}, Evidence)
+		require.NoError(t, err)
+
+		tmpl := makeCSRTemplate()
+		require.NoError(t, m.AddToCertificateRequest(tmpl, ChoiceCbor))
+
+		der, err := CreateCertificateRequestWithEvidence(rand.Reader, tmpl, key, ChoiceCbor, c, m)
+		require.NoError(t, err)
+
+		csr, err := x509.ParseCertificateRequest(der)
+		require.NoError(t, err)
+		assert.NoError(t, csr.CheckSignature())
+		assert.Equal(t, "attester.example", csr.Subject.CommonName)
+
+		items, err := DecodeCertificateRequest(csr)
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+
+		assert.Equal(t, CSRSourceExtension, items[0].Source)
+
+		assert.Equal(t, CSRSourceEvidenceAttribute, items[1].Source)
+		assert.Equal(t, 0, items[1].Index)
+		assert.Equal(t, KindCollection, items[1].CMW.GetKind())
+
+		assert.Equal(t, CSRSourceEvidenceAttribute, items[2].Source)
+		assert.Equal(t, 1, items[2].Index)
+		assert.Equal(t, KindMonad, items[2].CMW.GetKind())
+	}
+}
+
+func TestCreateCertificateRequestWithEvidence_ko(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = CreateCertificateRequestWithEvidence(rand.Reader, makeCSRTemplate(), key, ChoiceCbor)
+	assert.EqualError(t, err, "no CMW supplied")
+
+	_, err = CreateCertificateRequestWithEvidence(rand.Reader, makeCSRTemplate(), key, ChoiceCbor, &CMW{})
+	assert.EqualError(t, err, "CMW 0: CBOR encoding failed: unknown CMW kind")
+}
+
+func TestDecodeCertificateRequest_ko(t *testing.T) {
+	_, err := DecodeCertificateRequest(nil)
+	assert.EqualError(t, err, "nil CSR")
+
+	csr := &x509.CertificateRequest{
+		Extensions: []pkix.Extension{
+			{Id: OidExtCmw, Value: []byte{0x04, 0x01, 0x00}},
+		},
+	}
+
+	_, err = DecodeCertificateRequest(csr)
+	assert.ErrorContains(t, err, "extension request 0: decoding the wrapped CMW")
+}
@@ -0,0 +1,273 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+)
+
+// Domain separation prefixes for the Merkle tree hashes (as in RFC 9162)
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// Digest returns the digest of the deterministic CBOR encoding of the CMW
+// computed using h.  Monad values backed by a reader are hashed without
+// being loaded in memory.
+func (o CMW) Digest(h crypto.Hash) ([]byte, error) {
+	hh, err := newHash(h)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.WriteCBOR(hh); err != nil {
+		return nil, fmt.Errorf("encoding CMW: %w", err)
+	}
+
+	return hh.Sum(nil), nil
+}
+
+// MerkleRoot returns the root of a Merkle tree computed over the CMW using h.
+//
+// The leaves of a collection's tree are its items (and its __cmwc_t, if set)
+// sorted by their deterministically encoded key.  Each leaf hash is computed
+// as H(0x00 || key || item-digest), where key is the CBOR-encoded key, and
+// item-digest is the MerkleRoot of the item: for a monad, that is its Digest;
+// for a nested collection, the root of its own tree; for __cmwc_t, the digest
+// of the CBOR-encoded collection type.  Interior nodes are computed as
+// H(0x01 || left || right) using the tree shape defined in RFC 9162.
+//
+// Unlike Digest, changing one item only affects the hashes on its path to the
+// root, and the presence of an item can be proven using an InclusionProof.
+func (o CMW) MerkleRoot(h crypto.Hash) ([]byte, error) {
+	if _, err := newHash(h); err != nil {
+		return nil, err
+	}
+
+	return o.merkleRoot(h)
+}
+
+func (o CMW) merkleRoot(h crypto.Hash) ([]byte, error) {
+	switch o.kind {
+	case KindMonad:
+		return o.Digest(h)
+	case KindCollection:
+		leaves, err := o.collection.merkleLeaves(h)
+		if err != nil {
+			return nil, err
+		}
+		return merkleTreeHash(h, leaves), nil
+	default:
+		return nil, errors.New("unknown CMW kind")
+	}
+}
+
+type merkleLeaf struct {
+	key  any
+	enc  []byte
+	hash []byte
+}
+
+// merkleLeaves returns the (sorted) leaf hashes of the collection
+func (o collection) merkleLeaves(h crypto.Hash) ([]merkleLeaf, error) {
+	leaves := make([]merkleLeaf, 0, len(o.cmap)+1)
+
+	if o.ctyp != "" {
+		k, _ := em.Marshal(CmwCType)
+		ct, _ := em.Marshal(o.ctyp)
+		d := h.New()
+		d.Write(ct)
+		leaves = append(leaves, merkleLeaf{CmwCType, k, leafHash(h, k, d.Sum(nil))})
+	}
+
+	for k, v := range o.cmap {
+		enc, err := em.Marshal(k)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling collection key %v: %w", k, err)
+		}
+
+		d, err := v.merkleRoot(h)
+		if err != nil {
+			return nil, fmt.Errorf("hashing collection item %v: %w", k, err)
+		}
+
+		leaves = append(leaves, merkleLeaf{k, enc, leafHash(h, enc, d)})
+	}
+
+	sort.Slice(leaves, func(i, j int) bool { return bytes.Compare(leaves[i].enc, leaves[j].enc) < 0 })
+
+	return leaves, nil
+}
+
+// InclusionProof proves that an item is in a collection with a given
+// MerkleRoot, without revealing the other items
+type InclusionProof struct {
+	// Key is the key of the item in the collection
+	Key any
+	// Index is the position of the item among the (sorted) leaves
+	Index int
+	// Size is the number of leaves in the tree
+	Size int
+	// Path is the list of sibling hashes from the leaf up to the root
+	Path [][]byte
+}
+
+// InclusionProof returns a proof that the item at key is part of the
+// collection's Merkle tree (see MerkleRoot).  To prove the inclusion of an
+// item nested more deeply, chain the proofs of each level, since the item
+// digest of a nested collection is its MerkleRoot.
+func (o CMW) InclusionProof(h crypto.Hash, key any) (*InclusionProof, error) {
+	if o.kind != KindCollection {
+		return nil, fmt.Errorf("want collection, got %q", o.kind)
+	}
+
+	if _, err := newHash(h); err != nil {
+		return nil, err
+	}
+
+	leaves, err := o.collection.merkleLeaves(h)
+	if err != nil {
+		return nil, err
+	}
+
+	// integer keys of any Go type match the uint64/int64 keys stored in
+	// collections
+	key = normalizeInt(key)
+
+	for i, l := range leaves {
+		if l.key == key && key != CmwCType {
+			return &InclusionProof{
+				Key:   key,
+				Index: i,
+				Size:  len(leaves),
+				Path:  merklePath(h, i, leaves),
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("item not found for key %q", key)
+}
+
+// Verify checks that item, stored under o.Key, is included in the Merkle tree
+// with the supplied root
+func (o InclusionProof) Verify(h crypto.Hash, root []byte, item CMW) error {
+	if _, err := newHash(h); err != nil {
+		return err
+	}
+
+	if o.Index < 0 || o.Index >= o.Size {
+		return fmt.Errorf("index %d out of range for tree size %d", o.Index, o.Size)
+	}
+
+	enc, err := em.Marshal(o.Key)
+	if err != nil {
+		return fmt.Errorf("marshaling key %v: %w", o.Key, err)
+	}
+
+	d, err := item.merkleRoot(h)
+	if err != nil {
+		return fmt.Errorf("hashing item: %w", err)
+	}
+
+	// RFC 9162, Section 2.1.3.2
+	fn, sn := o.Index, o.Size-1
+	r := leafHash(h, enc, d)
+
+	for _, p := range o.Path {
+		if sn == 0 {
+			return errors.New("inclusion proof too long")
+		}
+
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(h, p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(h, r, p)
+		}
+
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return errors.New("inclusion proof too short")
+	}
+
+	if subtle.ConstantTimeCompare(r, root) != 1 {
+		return errors.New("inclusion proof does not match root")
+	}
+
+	return nil
+}
+
+func newHash(h crypto.Hash) (hash.Hash, error) {
+	if !h.Available() {
+		return nil, fmt.Errorf("hash function %v not available", h)
+	}
+	return h.New(), nil
+}
+
+func leafHash(h crypto.Hash, key, digest []byte) []byte {
+	hh := h.New()
+	hh.Write([]byte{merkleLeafPrefix})
+	hh.Write(key)
+	hh.Write(digest)
+	return hh.Sum(nil)
+}
+
+func nodeHash(h crypto.Hash, left, right []byte) []byte {
+	hh := h.New()
+	hh.Write([]byte{merkleNodePrefix})
+	hh.Write(left)
+	hh.Write(right)
+	return hh.Sum(nil)
+}
+
+// splitPoint returns the largest power of two smaller than n (n > 1)
+func splitPoint(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// merkleTreeHash computes MTH(D[n]) as in RFC 9162, Section 2.1.1
+func merkleTreeHash(h crypto.Hash, leaves []merkleLeaf) []byte {
+	switch n := len(leaves); n {
+	case 0:
+		return h.New().Sum(nil)
+	case 1:
+		return leaves[0].hash
+	default:
+		k := splitPoint(n)
+		return nodeHash(h, merkleTreeHash(h, leaves[:k]), merkleTreeHash(h, leaves[k:]))
+	}
+}
+
+// merklePath computes PATH(m, D[n]) as in RFC 9162, Section 2.1.3.1
+func merklePath(h crypto.Hash, m int, leaves []merkleLeaf) [][]byte {
+	n := len(leaves)
+	if n <= 1 {
+		return nil
+	}
+
+	k := splitPoint(n)
+
+	if m < k {
+		return append(merklePath(h, m, leaves[:k]), merkleTreeHash(h, leaves[k:]))
+	}
+
+	return append(merklePath(h, m-k, leaves[k:]), merkleTreeHash(h, leaves[:k]))
+}
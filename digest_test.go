@@ -0,0 +1,193 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeDigestTestCollection(t *testing.T, n int, ctyp string) *CMW {
+	c, err := NewCollection(ctyp)
+	require.NoError(t, err)
+
+	for i := 0; i < n; i++ {
+		m := mustNewMonad(t, "application/vnd.example", []byte{byte(i)})
+		require.NoError(t, c.AddCollectionItem(fmt.Sprintf("k%02d", i), m))
+	}
+
+	return c
+}
+
+func Test_Digest(t *testing.T) {
+	tv := mustReadFile(t, "testdata/collection-cbor-ok.cbor")
+
+	var c CMW
+	require.NoError(t, c.UnmarshalCBOR(tv))
+
+	actual, err := c.Digest(crypto.SHA256)
+	require.NoError(t, err)
+
+	expected := sha256.Sum256(tv)
+	assert.Equal(t, expected[:], actual)
+
+	_, err = c.Digest(crypto.Hash(0))
+	assert.EqualError(t, err, "hash function unknown hash value 0 not available")
+
+	_, err = CMW{}.Digest(crypto.SHA256)
+	assert.EqualError(t, err, "encoding CMW: unknown CMW kind")
+}
+
+func Test_MerkleRoot_monad(t *testing.T) {
+	m := mustNewMonad(t, "application/vnd.example", []byte{0x01})
+
+	d, err := m.Digest(crypto.SHA256)
+	require.NoError(t, err)
+
+	root, err := m.MerkleRoot(crypto.SHA256)
+	require.NoError(t, err)
+	assert.Equal(t, d, root)
+}
+
+func Test_MerkleRoot_single_item_change(t *testing.T) {
+	c := makeDigestTestCollection(t, 5, "")
+
+	root, err := c.MerkleRoot(crypto.SHA256)
+	require.NoError(t, err)
+
+	proof, err := c.InclusionProof(crypto.SHA256, "k00")
+	require.NoError(t, err)
+
+	m := mustNewMonad(t, "application/vnd.example", []byte{0xff})
+	require.NoError(t, c.AddCollectionItem("k04", m))
+
+	changed, err := c.MerkleRoot(crypto.SHA256)
+	require.NoError(t, err)
+	assert.NotEqual(t, root, changed)
+
+	// only the sibling hash on the path of the changed item is affected
+	newProof, err := c.InclusionProof(crypto.SHA256, "k00")
+	require.NoError(t, err)
+	require.Len(t, newProof.Path, 3)
+	assert.Equal(t, proof.Path[:2], newProof.Path[:2])
+	assert.NotEqual(t, proof.Path[2], newProof.Path[2])
+}
+
+func Test_InclusionProof_roundtrip(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		for _, ctyp := range []string{"", "tag:example.com,2024:composite-attester"} {
+			c := makeDigestTestCollection(t, n, ctyp)
+
+			root, err := c.MerkleRoot(crypto.SHA256)
+			require.NoError(t, err)
+
+			for i := 0; i < n; i++ {
+				key := fmt.Sprintf("k%02d", i)
+
+				t.Run(fmt.Sprintf("%d/%q/%s", n, ctyp, key), func(t *testing.T) {
+					proof, err := c.InclusionProof(crypto.SHA256, key)
+					require.NoError(t, err)
+
+					item, err := c.GetCollectionItem(key)
+					require.NoError(t, err)
+
+					assert.NoError(t, proof.Verify(crypto.SHA256, root, *item))
+
+					other := mustNewMonad(t, "application/vnd.example", []byte{0xff})
+					assert.EqualError(t, proof.Verify(crypto.SHA256, root, *other),
+						"inclusion proof does not match root")
+				})
+			}
+		}
+	}
+}
+
+func Test_InclusionProof_int_key(t *testing.T) {
+	c := makeDigestTestCollection(t, 2, "")
+	require.NoError(t, c.AddCollectionItem(uint64(1), mustNewMonad(t, "application/vnd.example", []byte{0x01})))
+	require.NoError(t, c.AddCollectionItem(int64(-1), mustNewMonad(t, "application/vnd.example", []byte{0x02})))
+
+	root, err := c.MerkleRoot(crypto.SHA256)
+	require.NoError(t, err)
+
+	for _, key := range []any{1, uint8(1), -1, int32(-1)} {
+		proof, err := c.InclusionProof(crypto.SHA256, key)
+		require.NoError(t, err, key)
+
+		item, err := c.GetCollectionItem(proof.Key)
+		require.NoError(t, err)
+		assert.NoError(t, proof.Verify(crypto.SHA256, root, *item))
+	}
+}
+
+func Test_InclusionProof_nested(t *testing.T) {
+	inner := makeDigestTestCollection(t, 3, "")
+	outer := makeDigestTestCollection(t, 2, "")
+	require.NoError(t, outer.AddCollectionItem("inner", inner))
+
+	root, err := outer.MerkleRoot(crypto.SHA256)
+	require.NoError(t, err)
+
+	innerRoot, err := inner.MerkleRoot(crypto.SHA256)
+	require.NoError(t, err)
+
+	outerProof, err := outer.InclusionProof(crypto.SHA256, "inner")
+	require.NoError(t, err)
+	assert.NoError(t, outerProof.Verify(crypto.SHA256, root, *inner))
+
+	innerProof, err := inner.InclusionProof(crypto.SHA256, "k01")
+	require.NoError(t, err)
+
+	item, err := inner.GetCollectionItem("k01")
+	require.NoError(t, err)
+	assert.NoError(t, innerProof.Verify(crypto.SHA256, innerRoot, *item))
+}
+
+func Test_InclusionProof_ko(t *testing.T) {
+	c := makeDigestTestCollection(t, 4, "tag:example.com,2024:composite-attester")
+
+	root, err := c.MerkleRoot(crypto.SHA256)
+	require.NoError(t, err)
+
+	_, err = c.InclusionProof(crypto.SHA256, "missing")
+	assert.EqualError(t, err, `item not found for key "missing"`)
+
+	_, err = c.InclusionProof(crypto.SHA256, CmwCType)
+	assert.EqualError(t, err, `item not found for key "__cmwc_t"`)
+
+	m, err := c.GetCollectionItem("k00")
+	require.NoError(t, err)
+
+	_, err = m.InclusionProof(crypto.SHA256, "k00")
+	assert.EqualError(t, err, `want collection, got "monad"`)
+
+	proof, err := c.InclusionProof(crypto.SHA256, "k00")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		mutate func(p *InclusionProof)
+		err    string
+	}{
+		{"wrong key", func(p *InclusionProof) { p.Key = "k01" }, "inclusion proof does not match root"},
+		{"wrong index", func(p *InclusionProof) { p.Index = 1 }, "inclusion proof does not match root"},
+		{"index out of range", func(p *InclusionProof) { p.Index = 5 }, "index 5 out of range for tree size 5"},
+		{"too short", func(p *InclusionProof) { p.Path = p.Path[1:] }, "inclusion proof too short"},
+		{"too long", func(p *InclusionProof) { p.Path = append(p.Path, p.Path[0]) }, "inclusion proof too long"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *proof
+			p.Path = append([][]byte(nil), proof.Path...)
+			tt.mutate(&p)
+			assert.EqualError(t, p.Verify(crypto.SHA256, root, *m), tt.err)
+		})
+	}
+}
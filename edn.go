@@ -0,0 +1,781 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MarshalEDN renders the CBOR serialization of the CMW in CBOR Extended
+// Diagnostic Notation (EDN, RFC 8610, Appendix G).  Media types of Tag CMWs
+// and of Content-Format record types, as well as indicators, are annotated
+// using "/ ... /" comments.  Since comments cannot contain slashes, those in
+// media types are rendered as U+2215 (DIVISION SLASH).
+func (o CMW) MarshalEDN() ([]byte, error) {
+	b, err := o.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+
+	return cborToEDN(b)
+}
+
+// UnmarshalEDN parses the supplied EDN text and decodes the resulting CBOR
+// data item into the target CMW.  Both "/ ... /" and end-of-line "# ..."
+// comments are accepted.  Data items may be nested at most 32 levels deep.
+func (o *CMW) UnmarshalEDN(b []byte) error {
+	c, err := ednToCBOR(b)
+	if err != nil {
+		return fmt.Errorf("parsing EDN: %w", err)
+	}
+
+	return o.UnmarshalCBOR(c)
+}
+
+// ednContext tells the renderer what CMW element (if any) a data item
+// represents, so that it can be annotated
+type ednContext int
+
+const (
+	ednPlain ednContext = iota
+	ednCMW
+	ednType
+	ednIndicator
+)
+
+const ednIndent = "  "
+
+// maximum nesting level of arrays, maps and tags, as in the CBOR decoder
+const ednMaxNesting = 32
+
+var errEDNNesting = fmt.Errorf("exceeded max nesting level (%d)", ednMaxNesting)
+
+type ednWriter struct {
+	b   []byte
+	out bytes.Buffer
+
+	nesting int
+}
+
+func cborToEDN(b []byte) ([]byte, error) {
+	w := ednWriter{b: b}
+
+	comment, err := w.item(ednCMW, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(w.b) != 0 {
+		return nil, fmt.Errorf("%d bytes of trailing data", len(w.b))
+	}
+
+	w.endLine(comment)
+
+	return w.out.Bytes(), nil
+}
+
+func (w *ednWriter) endLine(comment string) {
+	if comment != "" {
+		w.out.WriteString(" / " + strings.ReplaceAll(comment, "/", "\u2215") + " /")
+	}
+	w.out.WriteByte('\n')
+}
+
+// head consumes the initial byte and argument of the next data item.  For
+// indefinite-length items, indef is set and arg is meaningless.
+func (w *ednWriter) head() (major, ai byte, arg uint64, indef bool, err error) {
+	if len(w.b) == 0 {
+		return 0, 0, 0, false, errors.New("unexpected end of CBOR data")
+	}
+
+	major, ai = w.b[0]>>5, w.b[0]&0x1f
+	w.b = w.b[1:]
+
+	switch {
+	case ai < 24:
+		arg = uint64(ai)
+	case ai <= 27:
+		n := 1 << (ai - 24)
+		if len(w.b) < n {
+			return 0, 0, 0, false, errors.New("unexpected end of CBOR data")
+		}
+		for _, c := range w.b[:n] {
+			arg = arg<<8 | uint64(c)
+		}
+		w.b = w.b[n:]
+	case ai == 31 && major >= 2 && major <= 5:
+		indef = true
+	default:
+		return 0, 0, 0, false, fmt.Errorf("malformed initial byte 0x%02x", major<<5|ai)
+	}
+
+	return major, ai, arg, indef, nil
+}
+
+func (w *ednWriter) atBreak() bool { return len(w.b) > 0 && w.b[0] == 0xff }
+
+// peekText returns the value of the next data item if it is a definite-length
+// text string
+func (w *ednWriter) peekText() (string, bool) {
+	save := w.b
+	defer func() { w.b = save }()
+
+	major, _, arg, indef, err := w.head()
+	if err != nil || major != 3 || indef || uint64(len(w.b)) < arg {
+		return "", false
+	}
+
+	return string(w.b[:arg]), true
+}
+
+// item renders the next data item and returns the comment (if any) that
+// should be appended to the line where the item ends
+func (w *ednWriter) item(ctx ednContext, depth int) (string, error) {
+	w.nesting++
+	defer func() { w.nesting-- }()
+
+	if w.nesting > ednMaxNesting {
+		return "", errEDNNesting
+	}
+
+	start := w.b
+
+	major, ai, arg, indef, err := w.head()
+	if err != nil {
+		return "", err
+	}
+
+	switch major {
+	case 0:
+		w.out.WriteString(strconv.FormatUint(arg, 10))
+		return annotateUint(ctx, arg), nil
+	case 1:
+		if arg == math.MaxUint64 {
+			w.out.WriteString("-18446744073709551616")
+		} else {
+			w.out.WriteString("-" + strconv.FormatUint(arg+1, 10))
+		}
+	case 2, 3:
+		if indef {
+			return "", errors.New("indefinite-length strings are not supported")
+		}
+		if uint64(len(w.b)) < arg {
+			return "", errors.New("unexpected end of CBOR data")
+		}
+		s := w.b[:arg]
+		w.b = w.b[arg:]
+		if major == 2 {
+			w.out.WriteString("h'" + hex.EncodeToString(s) + "'")
+			break
+		}
+		if !utf8.Valid(s) {
+			return "", errors.New("invalid UTF-8 in text string")
+		}
+		w.writeText(string(s))
+	case 4:
+		return "", w.array(ctx, arg, indef, depth)
+	case 5:
+		return "", w.mapping(ctx, arg, indef, depth)
+	case 6:
+		w.out.WriteString(strconv.FormatUint(arg, 10) + "(")
+		comment, err := w.item(ednPlain, depth)
+		if err != nil {
+			return "", err
+		}
+		w.out.WriteByte(')')
+		if ctx == ednCMW {
+			return annotateTag(arg), nil
+		}
+		return comment, nil
+	case 7:
+		return "", w.simple(start, ai, arg)
+	}
+
+	return "", nil
+}
+
+func (w *ednWriter) writeText(s string) {
+	var b bytes.Buffer
+
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+
+	w.out.Write(bytes.TrimRight(b.Bytes(), "\n"))
+}
+
+func (w *ednWriter) simple(start []byte, ai byte, arg uint64) error {
+	switch ai {
+	case 20:
+		w.out.WriteString("false")
+	case 21:
+		w.out.WriteString("true")
+	case 22:
+		w.out.WriteString("null")
+	case 23:
+		w.out.WriteString("undefined")
+	case 25, 26, 27:
+		var f float64
+		if err := dm.Unmarshal(start[:1+(1<<(ai-24))], &f); err != nil {
+			return fmt.Errorf("decoding float: %w", err)
+		}
+		w.out.WriteString(formatEDNFloat(f))
+	default:
+		w.out.WriteString("simple(" + strconv.FormatUint(arg, 10) + ")")
+	}
+
+	return nil
+}
+
+func formatEDNFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+
+	return s
+}
+
+func (w *ednWriter) array(ctx ednContext, n uint64, indef bool, depth int) error {
+	if !indef && n == 0 {
+		w.out.WriteString("[]")
+		return nil
+	}
+
+	w.out.WriteString(openBracket('[', indef))
+
+	for i := uint64(0); indef && !w.atBreak() || !indef && i < n; i++ {
+		// record CMW: [ type, value, ?ind ]
+		elemCtx := ednPlain
+		if ctx == ednCMW {
+			switch i {
+			case 0:
+				elemCtx = ednType
+			case 2:
+				elemCtx = ednIndicator
+			}
+		}
+
+		w.out.WriteString(strings.Repeat(ednIndent, depth+1))
+
+		comment, err := w.item(elemCtx, depth+1)
+		if err != nil {
+			return err
+		}
+
+		if indef && !w.atBreak() || !indef && i+1 < n {
+			w.out.WriteByte(',')
+		}
+
+		w.endLine(comment)
+	}
+
+	return w.closeBracket(']', indef, depth)
+}
+
+func (w *ednWriter) mapping(ctx ednContext, n uint64, indef bool, depth int) error {
+	if !indef && n == 0 {
+		w.out.WriteString("{}")
+		return nil
+	}
+
+	w.out.WriteString(openBracket('{', indef))
+
+	for i := uint64(0); indef && !w.atBreak() || !indef && i < n; i++ {
+		// collection CMW: every value except the collection type is a CMW
+		valCtx := ednPlain
+		if ctx == ednCMW {
+			if k, ok := w.peekText(); !ok || k != CmwCType {
+				valCtx = ednCMW
+			}
+		}
+
+		w.out.WriteString(strings.Repeat(ednIndent, depth+1))
+
+		if _, err := w.item(ednPlain, depth+1); err != nil {
+			return err
+		}
+
+		w.out.WriteString(": ")
+
+		comment, err := w.item(valCtx, depth+1)
+		if err != nil {
+			return err
+		}
+
+		if indef && !w.atBreak() || !indef && i+1 < n {
+			w.out.WriteByte(',')
+		}
+
+		w.endLine(comment)
+	}
+
+	return w.closeBracket('}', indef, depth)
+}
+
+func openBracket(c byte, indef bool) string {
+	if indef {
+		return string(c) + "_\n"
+	}
+	return string(c) + "\n"
+}
+
+func (w *ednWriter) closeBracket(c byte, indef bool, depth int) error {
+	if indef {
+		if !w.atBreak() {
+			return errors.New("unexpected end of CBOR data")
+		}
+		w.b = w.b[1:]
+	}
+
+	w.out.WriteString(strings.Repeat(ednIndent, depth) + string(c))
+
+	return nil
+}
+
+func annotateUint(ctx ednContext, v uint64) string {
+	switch ctx {
+	case ednType:
+		if v > math.MaxUint16 {
+			break
+		}
+		if mt, ok := cf2mt[uint16(v)]; ok {
+			return mt
+		}
+	case ednIndicator:
+		return Indicator(v).String()
+	}
+
+	return ""
+}
+
+func annotateTag(tn uint64) string {
+	cf, err := CF(tn)
+	if err != nil {
+		return ""
+	}
+
+	if mt, ok := cf2mt[cf]; ok {
+		return fmt.Sprintf("C-F %d: %s", cf, mt)
+	}
+
+	return fmt.Sprintf("C-F %d", cf)
+}
+
+type ednParser struct {
+	s   string
+	pos int
+
+	nesting int
+}
+
+func ednToCBOR(b []byte) ([]byte, error) {
+	p := ednParser{s: string(b)}
+
+	out, err := p.item(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected trailing data")
+	}
+
+	return out, nil
+}
+
+func (p *ednParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("at offset %d: %s", p.pos, fmt.Sprintf(format, a...))
+}
+
+// skip consumes whitespace and comments
+func (p *ednParser) skip() error {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '/':
+			end := strings.IndexByte(p.s[p.pos+1:], '/')
+			if end < 0 {
+				return p.errorf("unterminated comment")
+			}
+			p.pos += end + 2
+		case '#':
+			end := strings.IndexByte(p.s[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.s)
+			} else {
+				p.pos += end + 1
+			}
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (p *ednParser) consume(prefix string) bool {
+	if strings.HasPrefix(p.s[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *ednParser) expect(c byte) error {
+	if err := p.skip(); err != nil {
+		return err
+	}
+	if p.pos >= len(p.s) || p.s[p.pos] != c {
+		return p.errorf("expecting %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// item parses the next data item and appends its CBOR encoding to out
+func (p *ednParser) item(out []byte) ([]byte, error) {
+	p.nesting++
+	defer func() { p.nesting-- }()
+
+	if p.nesting > ednMaxNesting {
+		return nil, p.errorf("%v", errEDNNesting)
+	}
+
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+
+	if p.pos >= len(p.s) {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	switch c := p.s[p.pos]; {
+	case c == '[':
+		p.pos++
+		return p.container(out, 4, ']')
+	case c == '{':
+		p.pos++
+		return p.container(out, 5, '}')
+	case c == '"':
+		s, err := p.text()
+		if err != nil {
+			return nil, err
+		}
+		return append(appendHead(out, 3, uint64(len(s))), s...), nil
+	case c == '\'' || p.consume("h'") || p.consume("b64'"):
+		b, err := p.bytes(c)
+		if err != nil {
+			return nil, err
+		}
+		return append(appendHead(out, 2, uint64(len(b))), b...), nil
+	case p.consume("<<"):
+		return p.embedded(out)
+	case c == '-' || c >= '0' && c <= '9':
+		return p.number(out)
+	default:
+		return p.keyword(out)
+	}
+}
+
+// container parses the items of an array (major type 4) or map (major type 5)
+func (p *ednParser) container(out []byte, major byte, closing byte) ([]byte, error) {
+	indef := p.consume("_")
+
+	var (
+		items []byte
+		n     uint64
+		err   error
+	)
+
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+
+		if p.consume(string(closing)) {
+			break
+		}
+
+		if n > 0 {
+			if err := p.expect(','); err != nil {
+				return nil, err
+			}
+			if err := p.skip(); err != nil {
+				return nil, err
+			}
+			// tolerate a trailing comma
+			if p.consume(string(closing)) {
+				break
+			}
+		}
+
+		if items, err = p.item(items); err != nil {
+			return nil, err
+		}
+
+		if major == 5 {
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			if items, err = p.item(items); err != nil {
+				return nil, err
+			}
+		}
+
+		n++
+	}
+
+	if indef {
+		out = append(out, major<<5|31)
+		out = append(out, items...)
+		return append(out, 0xff), nil
+	}
+
+	return append(appendHead(out, major, n), items...), nil
+}
+
+func (p *ednParser) text() (string, error) {
+	start := p.pos
+
+	for i := p.pos + 1; i < len(p.s); i++ {
+		switch p.s[i] {
+		case '\\':
+			i++
+		case '"':
+			var s string
+			if err := json.Unmarshal([]byte(p.s[start:i+1]), &s); err != nil {
+				return "", p.errorf("bad text string: %v", err)
+			}
+			p.pos = i + 1
+			return s, nil
+		}
+	}
+
+	return "", p.errorf("unterminated text string")
+}
+
+// bytes parses a byte string literal: h'hex', b64'base64' or 'text'.  The
+// caller has already consumed the h' and b64' prefixes; kind is the first
+// character of the literal.
+func (p *ednParser) bytes(kind byte) ([]byte, error) {
+	if kind == '\'' {
+		p.pos++
+	}
+
+	start := p.pos
+
+	var body strings.Builder
+
+	for ; p.pos < len(p.s) && p.s[p.pos] != '\''; p.pos++ {
+		// escapes are only meaningful in text byte strings
+		if p.s[p.pos] == '\\' && kind == '\'' && p.pos+1 < len(p.s) {
+			p.pos++
+		}
+		body.WriteByte(p.s[p.pos])
+	}
+
+	if p.pos >= len(p.s) {
+		p.pos = start
+		return nil, p.errorf("unterminated byte string")
+	}
+
+	p.pos++ // closing quote
+
+	s := body.String()
+
+	switch kind {
+	case 'h':
+		b, err := hexDecode(strings.ReplaceAll(s, "\r", ""))
+		if err != nil {
+			return nil, fmt.Errorf("at offset %d: bad hex byte string: %w", start, err)
+		}
+		return b, nil
+	case 'b':
+		s = strings.TrimRight(strings.Join(strings.Fields(s), ""), "=")
+		enc := base64.RawURLEncoding
+		if strings.ContainsAny(s, "+/") {
+			enc = base64.RawStdEncoding
+		}
+		b, err := enc.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("at offset %d: bad base64 byte string: %w", start, err)
+		}
+		return b, nil
+	default:
+		return []byte(s), nil
+	}
+}
+
+// embedded parses an embedded CBOR sequence (<< ... >>) into a byte string
+func (p *ednParser) embedded(out []byte) ([]byte, error) {
+	var (
+		seq []byte
+		err error
+	)
+
+	for i := 0; ; i++ {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.consume(">>") {
+			break
+		}
+		if i > 0 {
+			if err := p.expect(','); err != nil {
+				return nil, err
+			}
+		}
+		if seq, err = p.item(seq); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(appendHead(out, 2, uint64(len(seq))), seq...), nil
+}
+
+func isNumberChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		c == '.' || c == '+' || c == '-'
+}
+
+func (p *ednParser) number(out []byte) ([]byte, error) {
+	start := p.pos
+
+	if p.consume("-Infinity") {
+		return p.float(out, math.Inf(-1))
+	}
+
+	p.pos++ // sign or first digit
+	for p.pos < len(p.s) && isNumberChar(p.s[p.pos]) {
+		p.pos++
+	}
+
+	tok := p.s[start:p.pos]
+	neg := strings.HasPrefix(tok, "-")
+	mag := strings.TrimPrefix(tok, "-")
+
+	base := 10
+	if len(mag) > 1 && mag[0] == '0' && strings.ContainsRune("xXoObB", rune(mag[1])) {
+		base = 0
+	} else if strings.ContainsAny(mag, ".eE") {
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("bad number %q", tok)
+		}
+		return p.float(out, f)
+	}
+
+	v, err := strconv.ParseUint(mag, base, 64)
+	if err != nil {
+		// the magnitude of the smallest negative integer, -2^64, does not
+		// fit in a uint64
+		if neg && isTwoToThe64(mag, base) {
+			return appendHead(out, 1, math.MaxUint64), nil
+		}
+		p.pos = start
+		return nil, p.errorf("bad integer %q", tok)
+	}
+
+	// tag, possibly with whitespace or comments before the opening
+	// parenthesis
+	end := p.pos
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+	if !neg && p.consume("(") {
+		if out, err = p.item(appendHead(out, 6, v)); err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+
+	p.pos = end
+
+	if neg && v > 0 {
+		return appendHead(out, 1, v-1), nil
+	}
+
+	return appendHead(out, 0, v), nil
+}
+
+func isTwoToThe64(mag string, base int) bool {
+	v, ok := new(big.Int).SetString(mag, base)
+	return ok && v.Cmp(new(big.Int).Lsh(big.NewInt(1), 64)) == 0
+}
+
+func (p *ednParser) float(out []byte, f float64) ([]byte, error) {
+	b, err := em.Marshal(f)
+	if err != nil {
+		return nil, p.errorf("encoding float: %v", err)
+	}
+	return append(out, b...), nil
+}
+
+func (p *ednParser) keyword(out []byte) ([]byte, error) {
+	for _, kw := range []struct {
+		name string
+		enc  byte
+	}{
+		{"false", 0xf4},
+		{"true", 0xf5},
+		{"null", 0xf6},
+		{"undefined", 0xf7},
+	} {
+		if p.consume(kw.name) {
+			return append(out, kw.enc), nil
+		}
+	}
+
+	switch {
+	case p.consume("NaN"):
+		return p.float(out, math.NaN())
+	case p.consume("Infinity"):
+		return p.float(out, math.Inf(1))
+	case p.consume("simple("):
+		end := strings.IndexByte(p.s[p.pos:], ')')
+		if end < 0 {
+			return nil, p.errorf("unterminated simple value")
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(p.s[p.pos:p.pos+end]), 10, 8)
+		if err != nil || v >= 24 && v < 32 {
+			return nil, p.errorf("bad simple value")
+		}
+		p.pos += end + 1
+		if v < 24 {
+			return append(out, 0xe0|byte(v)), nil
+		}
+		return append(out, 0xf8, byte(v)), nil
+	}
+
+	return nil, p.errorf("unexpected character %q", p.s[p.pos])
+}
@@ -0,0 +1,256 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EDN_testdata_ok(t *testing.T) {
+	diags, err := filepath.Glob("testdata/*.diag")
+	require.NoError(t, err)
+	require.NotEmpty(t, diags)
+
+	for _, diag := range diags {
+		t.Run(filepath.Base(diag), func(t *testing.T) {
+			expected := mustReadFile(t, strings.TrimSuffix(diag, ".diag")+".cbor")
+
+			actual, err := ednToCBOR(mustReadFile(t, diag))
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func Test_MarshalEDN_collection(t *testing.T) {
+	var tv CMW
+	require.NoError(t, tv.UnmarshalCBOR(mustReadFile(t, "testdata/collection-cbor-ok.cbor")))
+
+	expected := `{
+  1: [
+    "application/signed-corim+cbor",
+    h'd28443a10126a1',
+    3 / endorsements, reference values /
+  ],
+  2: 1668576818(h'2347da55'), / C-F 29884 /
+  "s": [
+    30001,
+    h'2347da55'
+  ]
+}
+`
+
+	actual, err := tv.MarshalEDN()
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(actual))
+}
+
+func Test_MarshalEDN_nested_json_collection(t *testing.T) {
+	var tv CMW
+	require.NoError(t, tv.UnmarshalJSON([]byte(`{
+		"__cmwc_t": "tag:example.com,2025:x",
+		"a": ["application/ce+cbor", "oQo", 4],
+		"b": { "__cmwc_t": "1.2.3.4", "c": ["application/vnd.c", "Yw"] }
+	}`)))
+
+	expected := `{
+  "a": [
+    "application/ce+cbor",
+    h'a10a',
+    4 / evidence /
+  ],
+  "b": {
+    "c": [
+      "application/vnd.c",
+      h'63'
+    ],
+    "__cmwc_t": "1.2.3.4"
+  },
+  "__cmwc_t": "tag:example.com,2025:x"
+}
+`
+
+	actual, err := tv.MarshalEDN()
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(actual))
+}
+
+func Test_MarshalEDN_monads(t *testing.T) {
+	tag, err := NewMonad(uint16(10571), []byte{0xa1, 0x0a})
+	require.NoError(t, err)
+	tag.UseCBORTagFormat()
+
+	cf, err := NewMonad(uint16(10571), []byte{0xa1, 0x0a}, Evidence|AttestationResults)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		tv       *CMW
+		expected string
+	}{
+		{"tag", tag, "1668557429(h'a10a') / C-F 10571: application\u2215ce+cbor /\n"},
+		{"record with C-F", cf, "[\n  10571, / application\u2215ce+cbor /\n  h'a10a',\n  12 / attestation results, evidence /\n]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := tt.tv.MarshalEDN()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(actual))
+
+			// and back
+			var back CMW
+			require.NoError(t, back.UnmarshalEDN(actual))
+			expected, err := tt.tv.MarshalCBOR()
+			require.NoError(t, err)
+			roundtrip, err := back.MarshalCBOR()
+			require.NoError(t, err)
+			assert.Equal(t, expected, roundtrip)
+		})
+	}
+}
+
+func Test_EDN_roundtrip(t *testing.T) {
+	for _, fn := range []string{
+		"testdata/collection-cbor-ok.cbor",
+		"testdata/collection-cbor-ok-2.cbor",
+		"testdata/collection-cbor-mixed-keys.cbor",
+	} {
+		t.Run(filepath.Base(fn), func(t *testing.T) {
+			expected := mustReadFile(t, fn)
+
+			edn, err := cborToEDN(expected)
+			require.NoError(t, err)
+
+			actual, err := ednToCBOR(edn)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func Test_ednToCBOR_literals(t *testing.T) {
+	tests := []struct {
+		edn      string
+		expected string
+	}{
+		{`0`, "00"},
+		{`23`, "17"},
+		{`24`, "1818"},
+		{`0x10000`, "1a00010000"},
+		{`-1`, "20"},
+		{`-0`, "00"},
+		{`-500`, "3901f3"},
+		{`18446744073709551615`, "1bffffffffffffffff"},
+		{`-18446744073709551616`, "3bffffffffffffffff"},
+		{`-0x10000000000000000`, "3bffffffffffffffff"},
+		{`1.5`, "f93e00"},
+		{`-Infinity`, "f9fc00"},
+		{`true`, "f5"},
+		{`null`, "f6"},
+		{`simple(16)`, "f0"},
+		{`"a\"ü"`, "6461229fcbc"[:0] + "6461c3bc22"[:0] + "6461" + "22" + "c3bc"},
+		{`'it\'s'`, "4469742773"},
+		{`h'DE AD be ef'`, "44deadbeef"},
+		{`h''`, "40"},
+		{`b64'oQo'`, "42a10a"},
+		{`b64'+/8='`, "42fbff"},
+		{`<<1, "a">>`, "43016161"},
+		{`[_ 1, [], {}]`, "9f0180a0ff"},
+		{`{_ "a": 1, }`, "bf616101ff"},
+		{`1668576818(h'2347da55') / C-F 29884 /`, "da63747632442347da55"},
+		{`24 (h'01')`, "d8184101"},
+		{`[24 / tag / (1), 2 ]`, "82d8180102"},
+		{"# leading comment\n[1, # one\n 2]", "820102"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.edn, func(t *testing.T) {
+			actual, err := ednToCBOR([]byte(tt.edn))
+			require.NoError(t, err)
+			assert.Equal(t, mustHexDecode(tt.expected), actual)
+		})
+	}
+}
+
+func Test_ednToCBOR_fail(t *testing.T) {
+	tests := []struct {
+		edn string
+		err string
+	}{
+		{``, "at offset 0: unexpected end of input"},
+		{`[1 2]`, `at offset 3: expecting ','`},
+		{`{1 2}`, `at offset 3: expecting ':'`},
+		{`"abc`, "at offset 0: unterminated text string"},
+		{`h'zz'`, "at offset 2: bad hex byte string: encoding/hex: invalid byte: U+007A 'z'"},
+		{`/ never ends`, "at offset 0: unterminated comment"},
+		{`1 2`, "at offset 2: unexpected trailing data"},
+		{`99999999999999999999`, `at offset 0: bad integer "99999999999999999999"`},
+		{`-18446744073709551617`, `at offset 0: bad integer "-18446744073709551617"`},
+		{strings.Repeat("[", 33) + strings.Repeat("]", 33), "at offset 32: exceeded max nesting level (32)"},
+		{strings.Repeat("6(", 33) + "0" + strings.Repeat(")", 33), "at offset 64: exceeded max nesting level (32)"},
+		{`simple(25)`, "at offset 7: bad simple value"},
+		{`nope`, `at offset 0: unexpected character 'n'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.edn, func(t *testing.T) {
+			_, err := ednToCBOR([]byte(tt.edn))
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func Test_cborToEDN_plain(t *testing.T) {
+	// non-CMW data items render without annotations
+	actual, err := cborToEDN(mustHexDecode("9f20f93e00f5f6f040c2410aff"))
+	require.NoError(t, err)
+	assert.Equal(t, "[_\n  -1,\n  1.5,\n  true,\n  null,\n  simple(16),\n  h'',\n  2(h'0a')\n]\n", string(actual))
+
+	_, err = cborToEDN(mustHexDecode("8201"))
+	assert.EqualError(t, err, "unexpected end of CBOR data")
+
+	_, err = cborToEDN(mustHexDecode("0101"))
+	assert.EqualError(t, err, "1 bytes of trailing data")
+
+	_, err = cborToEDN(append(bytes.Repeat([]byte{0x81}, 32), 0x00))
+	assert.EqualError(t, err, "exceeded max nesting level (32)")
+
+	_, err = cborToEDN(append(bytes.Repeat([]byte{0xc6}, 32), 0x00))
+	assert.EqualError(t, err, "exceeded max nesting level (32)")
+}
+
+func Test_EDN_roundtrip_literals(t *testing.T) {
+	for _, tv := range []string{
+		"3bffffffffffffffff", // -2^64
+		"1bffffffffffffffff",
+		"d8184101",
+		"9f20f93e00f5f6f040c2410aff",
+	} {
+		t.Run(tv, func(t *testing.T) {
+			edn, err := cborToEDN(mustHexDecode(tv))
+			require.NoError(t, err)
+
+			actual, err := ednToCBOR(edn)
+			require.NoError(t, err)
+			assert.Equal(t, mustHexDecode(tv), actual)
+		})
+	}
+}
+
+func Test_UnmarshalEDN_fail(t *testing.T) {
+	var c CMW
+
+	err := c.UnmarshalEDN([]byte(`[1, `))
+	assert.EqualError(t, err, "parsing EDN: at offset 4: unexpected end of input")
+
+	err = c.UnmarshalEDN([]byte(`"not a CMW"`))
+	assert.EqualError(t, err, "want CBOR map, CBOR array or CBOR Tag start symbols, got: 0x69")
+}
@@ -0,0 +1,466 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	cose "github.com/veraison/go-cose"
+)
+
+// Envelope identifies the kind of wrapping applied around a serialized CMW.
+// Envelope values can be combined (see WithEnvelopes).
+type Envelope uint
+
+const EnvelopeNone = Envelope(0)
+
+const (
+	// signed-cbor-cmw (COSE_Sign1)
+	EnvelopeSignedCBOR = Envelope(1 << iota)
+	// signed-json-cmw (JWS, flattened JSON or compact serialization)
+	EnvelopeSignedJSON
+	// COSE_Encrypt or COSE_Encrypt0
+	EnvelopeCOSEEncrypt
+	// COSE_Mac or COSE_Mac0
+	EnvelopeCOSEMac
+	// base64 (standard or URL-safe, padded or not) encoding of a CMW
+	EnvelopeBase64
+	// DER-encoded X.509 certificate with an id-pe-cmw extension
+	EnvelopeX509
+	// PEM-armoured CMW ("CMW" label) or X.509 certificate ("CERTIFICATE"
+	// label)
+	EnvelopePEM
+)
+
+func (o Envelope) String() string {
+	switch o {
+	case EnvelopeSignedCBOR:
+		return "signed-cbor-cmw"
+	case EnvelopeSignedJSON:
+		return "signed-json-cmw"
+	case EnvelopeCOSEEncrypt:
+		return "COSE encrypt"
+	case EnvelopeCOSEMac:
+		return "COSE mac"
+	case EnvelopeBase64:
+		return "base64"
+	case EnvelopeX509:
+		return "X.509 certificate"
+	case EnvelopePEM:
+		return "PEM"
+	case EnvelopeNone:
+		fallthrough
+	default:
+		return "none"
+	}
+}
+
+// Confidence expresses how certain a sniffing result is
+type Confidence uint
+
+const (
+	ConfidenceNone = Confidence(iota)
+	// only the leading bytes matched
+	ConfidenceLow
+	// the envelope structure was recognised, but the enclosed CMW could not
+	// be inspected (e.g., it is encrypted)
+	ConfidenceMedium
+	// the buffer (and the enclosed CMW, if any) was successfully decoded
+	ConfidenceHigh
+)
+
+func (o Confidence) String() string {
+	switch o {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	case ConfidenceNone:
+		fallthrough
+	default:
+		return "none"
+	}
+}
+
+// SniffResult is the outcome of SniffDetailed.  Envelope is the outermost
+// wrapping found in the buffer and Format is the serialization of the CMW found
+// once all the envelopes have been removed.  Format is FormatUnknown if the
+// enclosed CMW cannot be inspected.
+type SniffResult struct {
+	Format     Format
+	Envelope   Envelope
+	Confidence Confidence
+}
+
+// maximum number of envelopes that are peeled before giving up
+const maxEnvelopeDepth = 8
+
+// SniffDetailed is like Sniff, but it also recognises signed, encrypted,
+// MAC'd, base64-wrapped, X.509-carried and PEM-armoured CMWs.
+func SniffDetailed(b []byte) SniffResult {
+	return sniffDetailed(b, 0)
+}
+
+func sniffDetailed(b []byte, depth int) SniffResult {
+	if len(b) == 0 || depth > maxEnvelopeDepth {
+		return SniffResult{}
+	}
+
+	env, inner, conf := detectEnvelope(b)
+
+	if env == EnvelopeNone {
+		f := Sniff(b)
+		if f == FormatUnknown {
+			return SniffResult{}
+		}
+
+		conf = ConfidenceLow
+
+		var c CMW
+		if c.deserializePlain(b) == nil {
+			conf = ConfidenceHigh
+		}
+
+		return SniffResult{Format: f, Confidence: conf}
+	}
+
+	r := SniffResult{Envelope: env, Confidence: conf}
+
+	if inner == nil {
+		return r
+	}
+
+	ir := sniffDetailed(inner, depth+1)
+	if ir.Format == FormatUnknown {
+		if env == EnvelopeBase64 {
+			// just some text that happens to be base64
+			return SniffResult{}
+		}
+		r.Confidence = ConfidenceLow
+		return r
+	}
+
+	r.Format = ir.Format
+	if ir.Confidence < r.Confidence {
+		r.Confidence = ir.Confidence
+	}
+
+	return r
+}
+
+var (
+	jwsCompactRe = regexp.MustCompile(`^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+$`)
+	base64Re     = regexp.MustCompile(`^[A-Za-z0-9+/_-]+={0,2}$`)
+	pemPrefix    = []byte("-----BEGIN ")
+)
+
+const (
+	pemLabelCMW         = "CMW"
+	pemLabelCertificate = "CERTIFICATE"
+)
+
+// COSE message tags (RFC 9052)
+const (
+	coseTagEncrypt0 = 16
+	coseTagMac0     = 17
+	coseTagSign1    = 18
+	coseTagEncrypt  = 96
+	coseTagMac      = 97
+)
+
+// detectEnvelope returns the outermost envelope in b, if any, together with the
+// enclosed (unverified) payload, if it is visible, and the confidence in the
+// envelope detection.
+func detectEnvelope(b []byte) (Envelope, []byte, Confidence) {
+	switch {
+	case bytes.HasPrefix(b, pemPrefix):
+		blk, _ := pem.Decode(b)
+		if blk == nil {
+			return EnvelopePEM, nil, ConfidenceLow
+		}
+		switch blk.Type {
+		case pemLabelCMW, pemLabelCertificate:
+			return EnvelopePEM, blk.Bytes, ConfidenceHigh
+		}
+		return EnvelopeNone, nil, ConfidenceNone
+	case b[0] == 0xd2:
+		msg, err := decodeSignedCBOR(b)
+		if err != nil {
+			return EnvelopeSignedCBOR, nil, ConfidenceLow
+		}
+		if msg.Payload == nil {
+			return EnvelopeSignedCBOR, nil, ConfidenceMedium
+		}
+		return EnvelopeSignedCBOR, msg.Payload, ConfidenceHigh
+	case b[0] == 0xd0 || b[0] == 0xd1 || b[0] == 0xd8:
+		return detectCOSEEnvelope(b)
+	case startJSONCollection(b[0]):
+		if !isFlattenedJWS(b) {
+			return EnvelopeNone, nil, ConfidenceNone
+		}
+		return detectSignedJSON(b)
+	case b[0] == 0x30:
+		if payload, err := x509CertificatePayload(b); err == nil {
+			return EnvelopeX509, payload, ConfidenceHigh
+		}
+	}
+
+	s := bytes.TrimSpace(b)
+
+	if jwsCompactRe.Match(s) {
+		return detectSignedJSON(s)
+	}
+
+	if base64Re.Match(s) {
+		if v, err := base64Decode(s); err == nil && len(v) > 0 {
+			return EnvelopeBase64, v, ConfidenceHigh
+		}
+	}
+
+	return EnvelopeNone, nil, ConfidenceNone
+}
+
+func detectCOSEEnvelope(b []byte) (Envelope, []byte, Confidence) {
+	var (
+		tag cbor.RawTag
+		a   []cbor.RawMessage
+	)
+
+	if err := dm.Unmarshal(b, &tag); err != nil {
+		return EnvelopeNone, nil, ConfidenceNone
+	}
+
+	var env Envelope
+	switch tag.Number {
+	case coseTagEncrypt0, coseTagEncrypt:
+		env = EnvelopeCOSEEncrypt
+	case coseTagMac0, coseTagMac:
+		env = EnvelopeCOSEMac
+	default:
+		return EnvelopeNone, nil, ConfidenceNone
+	}
+
+	if err := dm.Unmarshal(tag.Content, &a); err != nil || len(a) < 3 {
+		return env, nil, ConfidenceLow
+	}
+
+	if env == EnvelopeCOSEEncrypt {
+		return env, nil, ConfidenceMedium
+	}
+
+	// COSE_Mac0 = [ protected, unprotected, payload, tag ]
+	// COSE_Mac = [ protected, unprotected, payload, tag, recipients ]
+	var payload []byte
+	if err := dm.Unmarshal(a[2], &payload); err != nil || payload == nil {
+		return env, nil, ConfidenceMedium
+	}
+
+	return env, payload, ConfidenceHigh
+}
+
+func detectSignedJSON(b []byte) (Envelope, []byte, Confidence) {
+	msg, err := decodeSignedJSON(b)
+	if err != nil {
+		return EnvelopeSignedJSON, nil, ConfidenceLow
+	}
+	if len(msg.Payload()) == 0 {
+		return EnvelopeSignedJSON, nil, ConfidenceMedium
+	}
+	return EnvelopeSignedJSON, msg.Payload(), ConfidenceHigh
+}
+
+// isFlattenedJWS distinguishes a JWS using the flattened JSON serialization from
+// a JSON collection: collection items are never JSON strings.
+func isFlattenedJWS(b []byte) bool {
+	var m map[string]json.RawMessage
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return false
+	}
+
+	for _, k := range []string{"protected", "signature"} {
+		v, ok := m[k]
+		if !ok || len(v) == 0 || v[0] != '"' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func base64Decode(b []byte) ([]byte, error) {
+	s := string(b)
+
+	for _, enc := range []*base64.Encoding{
+		base64.RawURLEncoding,
+		base64.URLEncoding,
+		base64.RawStdEncoding,
+		base64.StdEncoding,
+	} {
+		if v, err := enc.DecodeString(s); err == nil {
+			return v, nil
+		}
+	}
+
+	return nil, errors.New("not a base64 string")
+}
+
+// x509CertificatePayload returns the serialized CMW carried in the first
+// id-pe-cmw extension of the supplied DER-encoded certificate.
+func x509CertificatePayload(der []byte) ([]byte, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing X.509 certificate: %w", err)
+	}
+
+	for _, extn := range cert.Extensions {
+		if extn.Id.Equal(OidExtCmw) {
+			payload, _, err := x509ExtensionPayload(extn)
+			return payload, err
+		}
+	}
+
+	return nil, errors.New("no id-pe-cmw extension found in X.509 certificate")
+}
+
+// Decrypter opens COSE_Encrypt, COSE_Encrypt0, COSE_Mac and COSE_Mac0
+// envelopes, which are not natively processed by this package.  Decrypt
+// returns the serialized CMW protected by the envelope.  For MAC envelopes,
+// Decrypt MUST verify the authentication tag before returning the payload.
+type Decrypter interface {
+	Decrypt(env Envelope, b []byte) ([]byte, error)
+}
+
+type deserializeOptions struct {
+	envelopes    Envelope
+	coseVerifier cose.Verifier
+	jwsAlg       jwa.SignatureAlgorithm
+	jwsKey       any
+	decrypter    Decrypter
+}
+
+// DeserializeOption configures how Deserialize treats enveloped CMWs
+type DeserializeOption func(*deserializeOptions)
+
+// the envelopes that can be removed without a verifier or decrypter
+const plainEnvelopes = EnvelopeBase64 | EnvelopePEM | EnvelopeX509
+
+// enabled reports whether any envelope processing has been requested
+func (o deserializeOptions) enabled() bool {
+	return o.envelopes != EnvelopeNone ||
+		o.coseVerifier != nil ||
+		o.jwsKey != nil ||
+		o.decrypter != nil
+}
+
+// WithEnvelopes allows Deserialize to remove the supplied combination of
+// EnvelopeBase64, EnvelopePEM and EnvelopeX509 wrappers, e.g.:
+//
+//	c.Deserialize(b, cmw.WithEnvelopes(cmw.EnvelopeBase64|cmw.EnvelopePEM))
+//
+// The X.509 certificate carrying a CMW is neither validated nor checked against
+// any trust anchor: use VerifyX509Certificate for that.
+func WithEnvelopes(envs Envelope) DeserializeOption {
+	return func(o *deserializeOptions) { o.envelopes |= envs & plainEnvelopes }
+}
+
+// WithCOSEVerifier allows Deserialize to verify and unwrap signed-cbor-cmw
+// envelopes
+func WithCOSEVerifier(v cose.Verifier) DeserializeOption {
+	return func(o *deserializeOptions) { o.coseVerifier = v }
+}
+
+// WithJWSVerifier allows Deserialize to verify and unwrap signed-json-cmw
+// envelopes
+func WithJWSVerifier(alg jwa.SignatureAlgorithm, key any) DeserializeOption {
+	return func(o *deserializeOptions) {
+		o.jwsAlg = alg
+		o.jwsKey = key
+	}
+}
+
+// WithDecrypter allows Deserialize to unwrap COSE encrypt and mac envelopes
+func WithDecrypter(d Decrypter) DeserializeOption {
+	return func(o *deserializeOptions) { o.decrypter = d }
+}
+
+func (o *CMW) deserializeEnveloped(b []byte, opts *deserializeOptions, depth int) error {
+	if len(b) == 0 {
+		return errors.New("empty buffer")
+	}
+
+	if depth > maxEnvelopeDepth {
+		return fmt.Errorf("too many nested envelopes (max %d)", maxEnvelopeDepth)
+	}
+
+	if !opts.enabled() {
+		return o.deserializePlain(b)
+	}
+
+	env, _, _ := detectEnvelope(b)
+
+	if env&plainEnvelopes != 0 && opts.envelopes&env == 0 {
+		return fmt.Errorf("%s envelope: not enabled", env)
+	}
+
+	var (
+		inner []byte
+		err   error
+	)
+
+	switch env {
+	case EnvelopeNone:
+		return o.deserializePlain(b)
+	case EnvelopeSignedCBOR:
+		if opts.coseVerifier == nil {
+			return errors.New("signed-cbor-cmw envelope: no COSE verifier supplied")
+		}
+		return o.VerifyCBOR(opts.coseVerifier, b)
+	case EnvelopeSignedJSON:
+		if opts.jwsKey == nil {
+			return errors.New("signed-json-cmw envelope: no JWS verification key supplied")
+		}
+		return o.VerifyJSON(opts.jwsAlg, opts.jwsKey, bytes.TrimSpace(b))
+	case EnvelopeCOSEEncrypt, EnvelopeCOSEMac:
+		if opts.decrypter == nil {
+			return fmt.Errorf("%s envelope: no decrypter supplied", env)
+		}
+		if inner, err = opts.decrypter.Decrypt(env, b); err != nil {
+			return fmt.Errorf("%s envelope: %w", env, err)
+		}
+	case EnvelopePEM:
+		blk, _ := pem.Decode(b)
+		if blk == nil {
+			return errors.New("PEM envelope: no PEM block found")
+		}
+		inner = blk.Bytes
+	case EnvelopeBase64:
+		if inner, err = base64Decode(bytes.TrimSpace(b)); err != nil {
+			return fmt.Errorf("base64 envelope: %w", err)
+		}
+	case EnvelopeX509:
+		if inner, err = x509CertificatePayload(b); err != nil {
+			return fmt.Errorf("X.509 envelope: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported envelope %s", env)
+	}
+
+	if err := o.deserializeEnveloped(inner, opts, depth+1); err != nil {
+		return fmt.Errorf("%s envelope: %w", env, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cose "github.com/veraison/go-cose"
+)
+
+func mustMarshalCOSETag(t *testing.T, num uint64, payload []byte) []byte {
+	b, err := em.Marshal(cbor.Tag{
+		Number: num,
+		Content: []any{
+			[]byte{0xa1, 0x01, 0x05}, // {alg: HMAC 256/256}
+			map[any]any{},
+			payload,
+			[]byte{0xde, 0xad, 0xbe, 0xef},
+		},
+	})
+	require.NoError(t, err)
+	return b
+}
+
+type testDecrypter struct {
+	payload []byte
+}
+
+func (o testDecrypter) Decrypt(env Envelope, b []byte) ([]byte, error) {
+	if env != EnvelopeCOSEEncrypt {
+		return nil, errors.New("unexpected envelope")
+	}
+	return o.payload, nil
+}
+
+func Test_SniffDetailed(t *testing.T) {
+	in := makeCMWCollection()
+	c, err := in.MarshalCBOR()
+	require.NoError(t, err)
+	j, err := in.MarshalJSON()
+	require.NoError(t, err)
+
+	signer, _, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+	signedCBOR, err := in.SignCBOR(signer)
+	require.NoError(t, err)
+	detachedCBOR, _, err := in.SignCBORDetached(signer)
+	require.NoError(t, err)
+
+	skey, _ := getJWSKeys(t, testES256Key)
+	signedJSON, err := in.SignJSON(jwa.ES256, skey)
+	require.NoError(t, err)
+	detachedJSON, _, err := in.SignJSONDetached(jwa.ES256, skey)
+	require.NoError(t, err)
+
+	der := mustReadFile(t, "testdata/x509-cert+cbor-cmw.der")
+
+	tests := []struct {
+		name string
+		tv   []byte
+		want SniffResult
+	}{
+		{
+			"CBOR collection",
+			c,
+			SniffResult{FormatCBORCollection, EnvelopeNone, ConfidenceHigh},
+		},
+		{
+			"truncated CBOR collection",
+			c[:10],
+			SniffResult{FormatCBORCollection, EnvelopeNone, ConfidenceLow},
+		},
+		{
+			"JSON collection",
+			j,
+			SniffResult{FormatJSONCollection, EnvelopeNone, ConfidenceHigh},
+		},
+		{
+			"signed-cbor-cmw",
+			signedCBOR,
+			SniffResult{FormatCBORCollection, EnvelopeSignedCBOR, ConfidenceHigh},
+		},
+		{
+			"signed-cbor-cmw with detached payload",
+			detachedCBOR,
+			SniffResult{FormatUnknown, EnvelopeSignedCBOR, ConfidenceMedium},
+		},
+		{
+			"signed-json-cmw",
+			signedJSON,
+			SniffResult{FormatJSONCollection, EnvelopeSignedJSON, ConfidenceHigh},
+		},
+		{
+			"compact JWS with detached payload",
+			detachedJSON,
+			SniffResult{FormatUnknown, EnvelopeSignedJSON, ConfidenceMedium},
+		},
+		{
+			"COSE_Encrypt0",
+			mustMarshalCOSETag(t, coseTagEncrypt0, []byte("ciphertext")),
+			SniffResult{FormatUnknown, EnvelopeCOSEEncrypt, ConfidenceMedium},
+		},
+		{
+			"COSE_Mac0",
+			mustMarshalCOSETag(t, coseTagMac0, c),
+			SniffResult{FormatCBORCollection, EnvelopeCOSEMac, ConfidenceHigh},
+		},
+		{
+			"COSE_Mac with detached payload",
+			mustMarshalCOSETag(t, coseTagMac, nil),
+			SniffResult{FormatUnknown, EnvelopeCOSEMac, ConfidenceMedium},
+		},
+		{
+			"base64url",
+			[]byte(base64.RawURLEncoding.EncodeToString(c)),
+			SniffResult{FormatCBORCollection, EnvelopeBase64, ConfidenceHigh},
+		},
+		{
+			"base64 with trailing newline",
+			[]byte(base64.StdEncoding.EncodeToString(j) + "\n"),
+			SniffResult{FormatJSONCollection, EnvelopeBase64, ConfidenceHigh},
+		},
+		{
+			"X.509 certificate",
+			der,
+			SniffResult{FormatCBORCollection, EnvelopeX509, ConfidenceHigh},
+		},
+		{
+			"PEM CMW",
+			pem.EncodeToMemory(&pem.Block{Type: "CMW", Bytes: c}),
+			SniffResult{FormatCBORCollection, EnvelopePEM, ConfidenceHigh},
+		},
+		{
+			"PEM certificate",
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			SniffResult{FormatCBORCollection, EnvelopePEM, ConfidenceHigh},
+		},
+		{
+			"PEM private key",
+			pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{0x30, 0x00}}),
+			SniffResult{},
+		},
+		{
+			"base64 non-CMW",
+			[]byte("deadbeef"),
+			SniffResult{},
+		},
+		{
+			"text",
+			[]byte("hello, world"),
+			SniffResult{},
+		},
+		{
+			"empty",
+			[]byte{},
+			SniffResult{},
+		},
+		{
+			"truncated 4 bytes CBOR tag number",
+			[]byte{0xda, 0x74, 0x66},
+			SniffResult{FormatCBORTag, EnvelopeNone, ConfidenceLow},
+		},
+		{
+			"truncated 8 bytes CBOR tag number",
+			[]byte{0xdb, 0x7b},
+			SniffResult{FormatCBORTag, EnvelopeNone, ConfidenceLow},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SniffDetailed(tt.tv))
+		})
+	}
+}
+
+func Test_Deserialize_envelopes_ok(t *testing.T) {
+	in := makeCMWCollection()
+	c, err := in.MarshalCBOR()
+	require.NoError(t, err)
+
+	signer, verifier, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+	signedCBOR, err := in.SignCBOR(signer)
+	require.NoError(t, err)
+
+	skey, vkey := getJWSKeys(t, testES256Key)
+	signedJSON, err := in.SignJSON(jwa.ES256, skey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		tv   []byte
+		opts []DeserializeOption
+		want Format
+	}{
+		{
+			"PEM-armoured base64 signed-cbor-cmw",
+			pem.EncodeToMemory(&pem.Block{
+				Type:  "CMW",
+				Bytes: []byte(base64.StdEncoding.EncodeToString(signedCBOR)),
+			}),
+			[]DeserializeOption{WithCOSEVerifier(verifier), WithEnvelopes(EnvelopePEM | EnvelopeBase64)},
+			FormatCBORCollection,
+		},
+		{
+			"signed-json-cmw",
+			signedJSON,
+			[]DeserializeOption{WithJWSVerifier(jwa.ES256, vkey)},
+			FormatJSONCollection,
+		},
+		{
+			"COSE_Encrypt0",
+			mustMarshalCOSETag(t, coseTagEncrypt0, []byte("ciphertext")),
+			[]DeserializeOption{WithDecrypter(testDecrypter{c})},
+			FormatCBORCollection,
+		},
+		{
+			"X.509 certificate",
+			mustReadFile(t, "testdata/x509-cert+cbor-cmw.der"),
+			[]DeserializeOption{WithEnvelopes(EnvelopeX509)},
+			FormatCBORCollection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actual CMW
+			err := actual.Deserialize(tt.tv, tt.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, KindCollection, actual.GetKind())
+			assert.Equal(t, tt.want, actual.GetFormat())
+		})
+	}
+}
+
+func Test_Deserialize_envelopes_ko(t *testing.T) {
+	in := makeCMWCollection()
+
+	signer, _, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+	signedCBOR, err := in.SignCBOR(signer)
+	require.NoError(t, err)
+
+	skey, _ := getJWSKeys(t, testES256Key)
+	signedJSON, err := in.SignJSON(jwa.ES256, skey)
+	require.NoError(t, err)
+
+	b64 := WithEnvelopes(EnvelopeBase64)
+
+	tests := []struct {
+		name        string
+		tv          []byte
+		opts        []DeserializeOption
+		expectedErr string
+	}{
+		{
+			"signed-cbor-cmw without options",
+			signedCBOR,
+			nil,
+			"unknown start symbol for CMW: 0xd2",
+		},
+		{
+			"signed-cbor-cmw without verifier",
+			signedCBOR,
+			[]DeserializeOption{b64},
+			"signed-cbor-cmw envelope: no COSE verifier supplied",
+		},
+		{
+			"base64 signed-json-cmw without key",
+			[]byte(base64.RawURLEncoding.EncodeToString(signedJSON)),
+			[]DeserializeOption{b64},
+			"base64 envelope: signed-json-cmw envelope: no JWS verification key supplied",
+		},
+		{
+			"base64 not enabled",
+			[]byte(base64.RawURLEncoding.EncodeToString(signedCBOR)),
+			[]DeserializeOption{WithEnvelopes(EnvelopePEM)},
+			"base64 envelope: not enabled",
+		},
+		{
+			"X.509 not enabled",
+			mustReadFile(t, "testdata/x509-cert+cbor-cmw.der"),
+			[]DeserializeOption{b64},
+			"X.509 certificate envelope: not enabled",
+		},
+		{
+			"truncated 4 bytes CBOR tag number",
+			[]byte{0xda, 0x2e, 0xa2},
+			[]DeserializeOption{b64},
+			"decoding tag: unmarshal CMW CBOR Tag: truncated CBOR tag: want at least 5 bytes, got 3",
+		},
+		{
+			"truncated 8 bytes CBOR tag number",
+			[]byte{0xdb, 0xd9, 0xa2},
+			nil,
+			"decoding tag: unmarshal CMW CBOR Tag: truncated CBOR tag: want at least 9 bytes, got 3",
+		},
+		{
+			"COSE_Mac0 without decrypter",
+			mustMarshalCOSETag(t, coseTagMac0, []byte{0x00}),
+			[]DeserializeOption{b64},
+			"COSE mac envelope: no decrypter supplied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actual CMW
+			err := actual.Deserialize(tt.tv, tt.opts...)
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type equalOptions struct {
+	ignoreFormat     bool
+	ignoreIndicators bool
+	normalizeTypes   bool
+}
+
+// EqualOption configures the comparisons made by Equal and Diff
+type EqualOption func(*equalOptions)
+
+// IgnoreFormat makes Equal and Diff disregard the serialization format (JSON
+// or CBOR, record or tag) of monads and collections
+func IgnoreFormat() EqualOption {
+	return func(o *equalOptions) { o.ignoreFormat = true }
+}
+
+// IgnoreIndicators makes Equal and Diff disregard monad indicators
+func IgnoreIndicators() EqualOption {
+	return func(o *equalOptions) { o.ignoreIndicators = true }
+}
+
+// NormalizeTypes makes Equal and Diff compare monad types after resolving
+// Content-Format and CBOR tag numbers to media types through the registry and
+// normalizing case and parameter formatting.  Without it, types match only if
+// they are expressed in the same way.
+func NormalizeTypes() EqualOption {
+	return func(o *equalOptions) { o.normalizeTypes = true }
+}
+
+// Equal reports whether the target CMW and other have the same structure and
+// contents, as configured by opts.  Integer collection keys are compared by
+// value, regardless of their Go type.
+func (o CMW) Equal(other CMW, opts ...EqualOption) bool {
+	return len(Diff(o, other, opts...)) == 0
+}
+
+// DiffField is a bit map of the aspects of a node that differ
+type DiffField uint
+
+const (
+	DiffKind = DiffField(1 << iota)
+	DiffFormat
+	DiffType
+	DiffValue
+	DiffIndicator
+	DiffCollectionType
+)
+
+var diffFieldNames = []struct {
+	f    DiffField
+	name string
+}{
+	{DiffKind, "kind"},
+	{DiffFormat, "format"},
+	{DiffType, "type"},
+	{DiffValue, "value"},
+	{DiffIndicator, "indicator"},
+	{DiffCollectionType, "collection type"},
+}
+
+func (o DiffField) String() string {
+	var a []string
+
+	for _, n := range diffFieldNames {
+		if o&n.f != 0 {
+			a = append(a, n.name)
+		}
+	}
+
+	return strings.Join(a, ", ")
+}
+
+// DiffOp says whether an item has been added, removed or changed
+type DiffOp uint
+
+const (
+	DiffChanged = DiffOp(iota)
+	DiffAdded
+	DiffRemoved
+)
+
+func (o DiffOp) String() string {
+	switch o {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Difference describes a node that differs between two CMWs
+type Difference struct {
+	// Path is the list of collection keys leading to the node
+	Path []any
+	Op   DiffOp
+	// Fields is only set for DiffChanged
+	Fields DiffField
+}
+
+func (o Difference) String() string {
+	s := fmt.Sprintf("%s %s", o.Op, formatPath(o.Path))
+
+	if o.Op == DiffChanged {
+		s += ": " + o.Fields.String()
+	}
+
+	return s
+}
+
+// Diff returns the differences between a and b, as configured by opts, in
+// depth-first, sorted key order.  Items only found in b are reported as
+// added, those only found in a as removed.
+func Diff(a, b CMW, opts ...EqualOption) []Difference {
+	var eo equalOptions
+
+	for _, opt := range opts {
+		opt(&eo)
+	}
+
+	var diffs []Difference
+
+	eo.diff(nil, a, b, &diffs)
+
+	return diffs
+}
+
+func (o equalOptions) diff(p []any, a, b CMW, diffs *[]Difference) {
+	var f DiffField
+
+	if a.kind != b.kind {
+		f |= DiffKind
+	} else if !o.ignoreFormat && a.GetFormat() != b.GetFormat() {
+		f |= DiffFormat
+	}
+
+	switch {
+	case f&DiffKind != 0:
+	case a.kind == KindMonad:
+		f |= o.diffMonad(a.monad, b.monad)
+	case a.kind == KindCollection:
+		if a.collection.ctyp != b.collection.ctyp {
+			f |= DiffCollectionType
+		}
+	}
+
+	if f != 0 {
+		*diffs = append(*diffs, Difference{Path: append([]any(nil), p...), Op: DiffChanged, Fields: f})
+	}
+
+	if a.kind != KindCollection || b.kind != KindCollection {
+		return
+	}
+
+	aKeys, bKeys := keyIndex(a.collection), keyIndex(b.collection)
+
+	for _, k := range mergeKeys(aKeys, bKeys) {
+		ak, aFound := aKeys[k]
+		bk, bFound := bKeys[k]
+
+		switch {
+		case !bFound:
+			*diffs = append(*diffs, Difference{Path: append(append([]any(nil), p...), ak), Op: DiffRemoved})
+		case !aFound:
+			*diffs = append(*diffs, Difference{Path: append(append([]any(nil), p...), bk), Op: DiffAdded})
+		default:
+			o.diff(append(p, ak), a.collection.cmap[ak], b.collection.cmap[bk], diffs)
+		}
+	}
+}
+
+func (o equalOptions) diffMonad(a, b monad) DiffField {
+	var f DiffField
+
+	if o.normalizeTypes {
+		if normalizeMediaType(a.typ.String()) != normalizeMediaType(b.typ.String()) {
+			f |= DiffType
+		}
+	} else if a.typ.val != b.typ.val {
+		f |= DiffType
+	}
+
+	if !o.ignoreIndicators && a.ind != b.ind {
+		f |= DiffIndicator
+	}
+
+	if !valuesEqual(a, b) {
+		f |= DiffValue
+	}
+
+	return f
+}
+
+// valuesEqual compares monad values, reading reader-backed ones in chunks.
+// Values that cannot be read are considered different.
+func valuesEqual(a, b monad) bool {
+	if a.valueSize() != b.valueSize() {
+		return false
+	}
+
+	if a.src == nil && b.src == nil {
+		return bytes.Equal(a.val, b.val)
+	}
+
+	ra, err := a.valueReader()
+	if err != nil {
+		return false
+	}
+
+	rb, err := b.valueReader()
+	if err != nil {
+		return false
+	}
+
+	var bufA, bufB [32 << 10]byte
+
+	for {
+		na, errA := io.ReadFull(ra, bufA[:])
+		nb, errB := io.ReadFull(rb, bufB[:])
+
+		if na != nb || !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false
+		}
+
+		if errA != nil || errB != nil {
+			return (errA == io.EOF || errA == io.ErrUnexpectedEOF) &&
+				(errB == io.EOF || errB == io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+// keyIndex maps the normalized keys of the collection to the actual ones, so
+// that integer keys with the same value match regardless of their Go type
+func keyIndex(c collection) map[any]any {
+	idx := make(map[any]any, len(c.cmap))
+
+	for k := range c.cmap {
+		nk := k
+		if i, ok := k.(int64); ok && i >= 0 {
+			nk = uint64(i)
+		}
+		idx[nk] = k
+	}
+
+	return idx
+}
+
+// mergeKeys returns the (normalized) keys found in either index, sorted in
+// the same way as getMeta
+func mergeKeys(a, b map[any]any) []any {
+	keys := make([]Meta, 0, len(a)+len(b))
+
+	for k := range a {
+		keys = append(keys, Meta{Key: k})
+	}
+
+	for k := range b {
+		if _, found := a[k]; !found {
+			keys = append(keys, Meta{Key: k})
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].getKeyForSorting() < keys[j].getKeyForSorting()
+	})
+
+	out := make([]any, len(keys))
+	for i, m := range keys {
+		out[i] = m.Key
+	}
+
+	return out
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Equal_across_encodings(t *testing.T) {
+	var j CMW
+	require.NoError(t, j.UnmarshalJSON(mustReadFile(t, "testdata/collection-ok.json")))
+
+	var c CMW
+	require.NoError(t, c.UnmarshalCBOR(mustMarshalCBOR(t, &j)))
+
+	assert.True(t, j.Equal(j))
+	assert.False(t, j.Equal(c))
+	assert.True(t, j.Equal(c, IgnoreFormat()))
+
+	assert.Equal(t, []string{
+		"changed /: format",
+		`changed /"a": format`,
+		`changed /"b": format`,
+	}, diffStrings(Diff(j, c)))
+}
+
+func Test_Equal_types(t *testing.T) {
+	byCF := mustNewMonad(t, uint16(263), []byte{0x01})
+	byMT := mustNewMonad(t, "Application/EAT+CWT", []byte{0x01})
+
+	assert.False(t, byCF.Equal(*byMT))
+	assert.True(t, byCF.Equal(*byMT, NormalizeTypes()))
+
+	// a tag carries the tag number, a record the Content-Format
+	tag := mustNewMonad(t, uint16(30001), []byte{0x01})
+	tag.UseCBORTagFormat()
+
+	var decodedTag CMW
+	require.NoError(t, decodedTag.UnmarshalCBOR(mustMarshalCBOR(t, tag)))
+
+	var decodedRecord CMW
+	require.NoError(t, decodedRecord.UnmarshalCBOR(mustMarshalCBOR(t, mustNewMonad(t, uint16(30001), []byte{0x01}))))
+
+	assert.Equal(t, []string{"changed /: format, type"}, diffStrings(Diff(decodedTag, decodedRecord)))
+	assert.True(t, decodedTag.Equal(decodedRecord, IgnoreFormat(), NormalizeTypes()))
+}
+
+func Test_Equal_indicators(t *testing.T) {
+	a := mustNewMonad(t, "application/vnd.a", []byte{0x01}, Evidence)
+	b := mustNewMonad(t, "application/vnd.a", []byte{0x01}, Endorsements)
+
+	assert.False(t, a.Equal(*b))
+	assert.True(t, a.Equal(*b, IgnoreIndicators()))
+}
+
+func Test_Equal_integer_keys(t *testing.T) {
+	a, err := NewCollection("")
+	require.NoError(t, err)
+	require.NoError(t, a.AddCollectionItem(int64(1), mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+
+	b, err := NewCollection("")
+	require.NoError(t, err)
+	require.NoError(t, b.AddCollectionItem(uint64(1), mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+
+	assert.True(t, a.Equal(*b))
+}
+
+func Test_Equal_reader_backed(t *testing.T) {
+	value := bytes.Repeat([]byte{0x5a}, 100<<10)
+
+	inMemory := mustNewMonad(t, "application/vnd.a", value)
+
+	other := bytes.Clone(value)
+
+	streamed, err := NewMonadFromReader("application/vnd.a", bytes.NewReader(other), int64(len(other)))
+	require.NoError(t, err)
+	assert.True(t, inMemory.Equal(*streamed))
+
+	other[len(other)-1] = 0
+	assert.False(t, inMemory.Equal(*streamed))
+}
+
+func Test_Diff(t *testing.T) {
+	a := makeCMWCollection()
+	b := makeCMWCollection()
+
+	// change the type and value of an item, and the collection type of the
+	// nested collection
+	require.NoError(t, b.AddCollectionItem("bretwaldadom", mustNewMonad(t, "application/eat-ucs+json", []byte("{}"))))
+
+	sub, err := NewCollection("tag:ietf.org,2024:Z")
+	require.NoError(t, err)
+	polyscopic, err := a.collection.cmap["murmurless"].GetCollectionItem("polyscopic")
+	require.NoError(t, err)
+	require.NoError(t, sub.AddCollectionItem("polyscopic", polyscopic))
+	require.NoError(t, sub.AddCollectionItem("new", mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+	require.NoError(t, b.AddCollectionItem("murmurless", sub))
+
+	// drop an item, and add one with an integer key
+	delete(b.collection.cmap, "photoelectrograph")
+	require.NoError(t, b.AddCollectionItem(uint64(3), mustNewMonad(t, "application/vnd.a", []byte{0x01})))
+
+	diffs := Diff(*a, *b)
+
+	assert.Equal(t, []string{
+		`added /3`,
+		`changed /"bretwaldadom": type, value`,
+		`changed /"murmurless": collection type`,
+		`added /"murmurless"/"new"`,
+		`removed /"photoelectrograph"`,
+	}, diffStrings(diffs))
+
+	assert.Equal(t, Difference{Path: []any{"murmurless", "new"}, Op: DiffAdded}, diffs[3])
+
+	// symmetry
+	assert.Equal(t, []string{
+		`removed /3`,
+		`changed /"bretwaldadom": type, value`,
+		`changed /"murmurless": collection type`,
+		`removed /"murmurless"/"new"`,
+		`added /"photoelectrograph"`,
+	}, diffStrings(Diff(*b, *a)))
+}
+
+func Test_Diff_kind(t *testing.T) {
+	m := mustNewMonad(t, "application/vnd.a", []byte{0x01})
+
+	assert.Equal(t, []string{"changed /: kind"}, diffStrings(Diff(*m, *makeCMWCollection())))
+}
+
+func diffStrings(diffs []Difference) []string {
+	var s []string
+	for _, d := range diffs {
+		s = append(s, fmt.Sprint(d))
+	}
+	return s
+}
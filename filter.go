@@ -0,0 +1,158 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Predicate reports whether the node found at path (the list of collection
+// keys leading to it from the root) should be kept.  The path slice must not
+// be retained.
+type Predicate func(path []any, node *CMW) bool
+
+// Filter returns a new tree made of the nodes of the target CMW for which
+// pred returns true.  If pred returns false for a collection, the whole
+// collection is dropped; otherwise, its items are filtered in turn.  Any
+// collection left without items is removed, so that the result satisfies
+// collection validation, except for the profile checks (see RegisterProfile):
+// a filtered collection may lack the items its profile requires.  An error is
+// returned if nothing is left.
+//
+// The monad values of the new tree are shared with the target CMW.
+func (o CMW) Filter(pred func(path []any, node *CMW) bool) (*CMW, error) {
+	c, ok := filterNode(nil, o, pred)
+	if !ok {
+		return nil, errors.New("no items left after filtering")
+	}
+	return &c, nil
+}
+
+func filterNode(p []any, node CMW, pred func([]any, *CMW) bool) (CMW, bool) {
+	if !pred(p, &node) {
+		return CMW{}, false
+	}
+
+	if node.kind != KindCollection {
+		return node, true
+	}
+
+	out := CMW{
+		kind: KindCollection,
+		collection: collection{
+			cmap:   make(map[any]CMW),
+			ctyp:   node.collection.ctyp,
+			format: node.collection.format,
+		},
+	}
+
+	for _, m := range node.collection.getMeta() {
+		if item, ok := filterNode(append(p, m.Key), node.collection.cmap[m.Key], pred); ok {
+			out.collection.cmap[m.Key] = item
+		}
+	}
+
+	if len(out.collection.cmap) == 0 {
+		return CMW{}, false
+	}
+
+	return out, true
+}
+
+// The predicates below (and their combinations using Not, And and Or) only
+// select monads: collections are always descended into, and are dropped by
+// Filter if none of their items is selected.
+
+// ByIndicator selects the monads whose indicator has any of the bits in ind
+func ByIndicator(ind Indicator) Predicate {
+	return monadPredicate(func(_ []any, node *CMW) bool {
+		return node.monad.ind.Has(ind)
+	})
+}
+
+// ByMediaType selects the monads whose media type (ignoring parameters)
+// matches any of the supplied patterns, using the path.Match syntax, e.g.,
+// "application/eat+cwt" or "application/*"
+func ByMediaType(patterns ...string) Predicate {
+	return monadPredicate(func(_ []any, node *CMW) bool {
+		return matchMediaType(patterns, node.monad.typ)
+	})
+}
+
+// matchMediaType reports whether the media type of typ, without parameters,
+// matches any of the (case-insensitive) path.Match patterns
+func matchMediaType(patterns []string, typ Type) bool {
+	mt, _, _ := strings.Cut(typ.String(), ";")
+	mt = strings.ToLower(strings.TrimSpace(mt))
+
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), mt); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ByKeyPattern selects the monads whose collection key matches re.  Integer
+// keys are matched using their decimal representation.  A monad at the root
+// has no key and is always selected.
+func ByKeyPattern(re *regexp.Regexp) Predicate {
+	return monadPredicate(func(p []any, _ *CMW) bool {
+		if len(p) == 0 {
+			return true
+		}
+		return re.MatchString(fmt.Sprint(p[len(p)-1]))
+	})
+}
+
+// MaxDepth selects the monads nested in at most n collections
+func MaxDepth(n int) Predicate {
+	return monadPredicate(func(p []any, _ *CMW) bool {
+		return len(p) <= n
+	})
+}
+
+// Not selects the monads that are not selected by pred
+func Not(pred Predicate) Predicate {
+	return monadPredicate(func(p []any, node *CMW) bool {
+		return !pred(p, node)
+	})
+}
+
+// And selects the monads that are selected by all of preds
+func And(preds ...Predicate) Predicate {
+	return monadPredicate(func(p []any, node *CMW) bool {
+		for _, pred := range preds {
+			if !pred(p, node) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Or selects the monads that are selected by any of preds
+func Or(preds ...Predicate) Predicate {
+	return monadPredicate(func(p []any, node *CMW) bool {
+		for _, pred := range preds {
+			if pred(p, node) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func monadPredicate(pred Predicate) Predicate {
+	return func(p []any, node *CMW) bool {
+		if node.kind == KindCollection {
+			return true
+		}
+		return pred(p, node)
+	}
+}
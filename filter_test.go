@@ -0,0 +1,173 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectLeaves returns the paths of the monads in c, in the form "k1/k2"
+func collectLeaves(c *CMW) []string {
+	var leaves []string
+
+	var walk func(prefix string, n CMW)
+	walk = func(prefix string, n CMW) {
+		if n.kind == KindMonad {
+			leaves = append(leaves, prefix)
+			return
+		}
+		for _, m := range n.collection.getMeta() {
+			k := formatKey(m.Key)
+			if prefix != "" {
+				k = prefix + "/" + k
+			}
+			walk(k, n.collection.cmap[m.Key])
+		}
+	}
+
+	walk("", *c)
+
+	return leaves
+}
+
+func Test_Filter(t *testing.T) {
+	tests := []struct {
+		name     string
+		pred     Predicate
+		expected []string
+	}{
+		{
+			"indicator",
+			ByIndicator(AttestationResults),
+			[]string{`"murmurless"/"polyscopic"`},
+		},
+		{
+			"any of the indicators",
+			ByIndicator(AttestationResults | Endorsements),
+			[]string{`"murmurless"/"polyscopic"`, `"photoelectrograph"`},
+		},
+		{
+			"media type",
+			ByMediaType("application/eat-ucs+cbor"),
+			[]string{`"bretwaldadom"`, `"photoelectrograph"`},
+		},
+		{
+			"media type pattern",
+			ByMediaType("Application/*+JSON"),
+			[]string{`"murmurless"/"polyscopic"`},
+		},
+		{
+			"not media type",
+			Not(ByMediaType("application/eat-ucs+cbor")),
+			[]string{`"murmurless"/"polyscopic"`},
+		},
+		{
+			"key pattern",
+			ByKeyPattern(regexp.MustCompile(`^p`)),
+			[]string{`"murmurless"/"polyscopic"`, `"photoelectrograph"`},
+		},
+		{
+			"depth",
+			MaxDepth(1),
+			[]string{`"bretwaldadom"`, `"photoelectrograph"`},
+		},
+		{
+			"and",
+			And(MaxDepth(1), ByKeyPattern(regexp.MustCompile(`^p`))),
+			[]string{`"photoelectrograph"`},
+		},
+		{
+			"or",
+			Or(ByIndicator(AttestationResults), ByKeyPattern(regexp.MustCompile(`^b`))),
+			[]string{`"bretwaldadom"`, `"murmurless"/"polyscopic"`},
+		},
+		{
+			"custom, dropping a whole collection",
+			func(path []any, _ *CMW) bool { return len(path) == 0 || path[0] != "murmurless" },
+			[]string{`"bretwaldadom"`, `"photoelectrograph"`},
+		},
+	}
+
+	in := makeCMWCollection()
+	before := mustMarshalCBOR(t, in)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := in.Filter(tt.pred)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, collectLeaves(out))
+			assert.NoError(t, out.validate())
+
+			ctyp, _ := out.GetCollectionType()
+			assert.Equal(t, "tag:ietf.org,2024:X", ctyp)
+		})
+	}
+
+	// the original tree is untouched
+	assert.Equal(t, before, mustMarshalCBOR(t, in))
+}
+
+func Test_Filter_removes_empty_collections(t *testing.T) {
+	out, err := makeCMWCollection().Filter(Not(ByIndicator(AttestationResults)))
+	require.NoError(t, err)
+
+	_, err = out.GetCollectionItem("murmurless")
+	assert.EqualError(t, err, `item not found for key "murmurless"`)
+}
+
+func Test_Filter_drops_required_items(t *testing.T) {
+	registerTestProfiles(t)
+
+	c, err := Build().
+		Type(testProfileType).
+		Monad("ev", "application/eat+cwt", []byte{0x01}, Evidence).
+		Tag(1, uint16(30001), []byte{0x02}).
+		Done()
+	require.NoError(t, err)
+
+	// profiles are not enforced on the filtered tree
+	out, err := c.Filter(Not(ByIndicator(Evidence)))
+	require.NoError(t, err)
+
+	assert.EqualError(t, out.ValidateCollection(),
+		`collection type "tag:example.com,2025:profiled": missing required item ev`)
+}
+
+func Test_Filter_paths(t *testing.T) {
+	var paths [][]any
+
+	_, err := makeCMWCollection().Filter(func(path []any, _ *CMW) bool {
+		paths = append(paths, append([]any(nil), path...))
+		return true
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]any{
+		nil,
+		{"bretwaldadom"},
+		{"murmurless"},
+		{"murmurless", "polyscopic"},
+		{"photoelectrograph"},
+	}, paths)
+}
+
+func Test_Filter_nothing_left(t *testing.T) {
+	_, err := makeCMWCollection().Filter(ByIndicator(TrustAnchors))
+	assert.EqualError(t, err, "no items left after filtering")
+
+	m, err := NewMonad("application/vnd.example", []byte{0x01}, Evidence)
+	require.NoError(t, err)
+
+	out, err := m.Filter(ByIndicator(Evidence))
+	require.NoError(t, err)
+	assert.Equal(t, m, out)
+
+	_, err = m.Filter(ByIndicator(AttestationResults))
+	assert.EqualError(t, err, "no items left after filtering")
+}
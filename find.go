@@ -0,0 +1,99 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"fmt"
+	"math"
+	"mime"
+)
+
+// Match is a monad found in a CMW tree, together with the path (the list of
+// collection keys) leading to it from the root
+type Match struct {
+	Path []any
+	Node *CMW
+}
+
+// FindByIndicator returns the monads whose indicator has any of the bits in
+// ind, in depth-first, sorted key order.  If ind is IndicatorNone, the monads
+// without an indicator are returned.
+func (o CMW) FindByIndicator(ind Indicator) []Match {
+	return o.find(func(m *monad) bool {
+		if ind.Empty() {
+			return m.ind.Empty()
+		}
+		return m.ind.Has(ind)
+	})
+}
+
+// FindByType returns the monads with the supplied media type, in depth-first,
+// sorted key order.  The media type can be given as a string, or as a CoAP
+// Content-Format number (int or uint16).  Media types are compared after
+// resolving Content-Format numbers and CBOR tag numbers through the registry
+// and normalizing case and parameter formatting, so that, e.g., 263 matches a
+// monad of type "Application/EAT+CWT".
+func (o CMW) FindByType(mediaType any) ([]Match, error) {
+	var t Type
+
+	switch v := mediaType.(type) {
+	case int:
+		if v < 0 || v > math.MaxUint16 {
+			return nil, fmt.Errorf("bad Content-Format %d: out of range", v)
+		}
+		t.val = uint16(v)
+	case uint16:
+		t.val = v
+	case string:
+		if err := t.Set(v); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %T for media type, want string or Content-Format", v)
+	}
+
+	want := normalizeMediaType(t.String())
+
+	return o.find(func(m *monad) bool {
+		return normalizeMediaType(m.typ.String()) == want
+	}), nil
+}
+
+func (o CMW) find(pred func(*monad) bool) []Match {
+	var matches []Match
+
+	var walk func(p []any, node CMW)
+	walk = func(p []any, node CMW) {
+		switch node.kind {
+		case KindMonad:
+			if pred(&node.monad) {
+				matches = append(matches, Match{Path: append([]any(nil), p...), Node: &node})
+			}
+		case KindCollection:
+			for _, m := range node.collection.getMeta() {
+				walk(append(p, m.Key), node.collection.cmap[m.Key])
+			}
+		}
+	}
+
+	walk(nil, o)
+
+	return matches
+}
+
+// normalizeMediaType lower-cases type, subtype and parameter names, and
+// formats parameters consistently.  Strings that are not valid media types,
+// such as unregistered Content-Format numbers, are returned unchanged.
+func normalizeMediaType(s string) string {
+	mt, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return s
+	}
+
+	if n := mime.FormatMediaType(mt, params); n != "" {
+		return n
+	}
+
+	return s
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func matchPaths(matches []Match) [][]any {
+	var paths [][]any
+	for _, m := range matches {
+		paths = append(paths, m.Path)
+	}
+	return paths
+}
+
+func makeFindTestCollection(t *testing.T) *CMW {
+	c := makeCMWCollection()
+
+	byCF := mustNewMonad(t, uint16(263), []byte{0x01}, Evidence)
+	require.NoError(t, c.AddCollectionItem("by-cf", byCF))
+
+	tn, err := TN(263)
+	require.NoError(t, err)
+
+	byTN := mustNewMonad(t, tn, []byte{0x02}, Evidence)
+	byTN.UseCBORTagFormat()
+
+	sub, err := NewCollection("")
+	require.NoError(t, err)
+	require.NoError(t, sub.AddCollectionItem(uint64(7), byTN))
+
+	byMT := mustNewMonad(t, "Application/EAT+CWT", []byte{0x03}, AttestationResults)
+	require.NoError(t, sub.AddCollectionItem("by-mt", byMT))
+
+	require.NoError(t, c.AddCollectionItem("sub", sub))
+
+	return c
+}
+
+func Test_FindByIndicator(t *testing.T) {
+	c := makeFindTestCollection(t)
+
+	tests := []struct {
+		name     string
+		ind      Indicator
+		expected [][]any
+	}{
+		{"evidence", Evidence, [][]any{{"by-cf"}, {"sub", uint64(7)}}},
+		{"attestation results", AttestationResults, [][]any{{"murmurless", "polyscopic"}, {"sub", "by-mt"}}},
+		{"reference values", ReferenceValues, [][]any{{"photoelectrograph"}}},
+		{"none", IndicatorNone, [][]any{{"bretwaldadom"}}},
+		{"trust anchors", TrustAnchors, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchPaths(c.FindByIndicator(tt.ind)))
+		})
+	}
+}
+
+func Test_FindByType(t *testing.T) {
+	c := makeFindTestCollection(t)
+
+	tests := []struct {
+		name      string
+		mediaType any
+		expected  [][]any
+	}{
+		{"CF as int", 263, [][]any{{"by-cf"}, {"sub", uint64(7)}, {"sub", "by-mt"}}},
+		{"CF as uint16", uint16(263), [][]any{{"by-cf"}, {"sub", uint64(7)}, {"sub", "by-mt"}}},
+		{"media type", "application/eat+cwt", [][]any{{"by-cf"}, {"sub", uint64(7)}, {"sub", "by-mt"}}},
+		{"media type, not normalized", "application/EAT-UCS+CBOR", [][]any{{"bretwaldadom"}, {"photoelectrograph"}}},
+		{"no match", "application/json", nil},
+		{"unregistered CF", 65000, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := c.FindByType(tt.mediaType)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, matchPaths(actual))
+		})
+	}
+}
+
+func Test_FindByType_node(t *testing.T) {
+	actual, err := makeCMWCollection().FindByType("application/eat-ucs+json")
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+
+	v, err := actual[0].Node.GetMonadValue()
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"eat_nonce": ...}`), v)
+
+	m := mustNewMonad(t, "application/vnd.example", []byte{0x01})
+
+	actual, err = m.FindByType("application/vnd.example")
+	require.NoError(t, err)
+	assert.Equal(t, []Match{{Path: nil, Node: m}}, actual)
+}
+
+func Test_FindByType_ko(t *testing.T) {
+	c := makeCMWCollection()
+
+	_, err := c.FindByType(70000)
+	assert.EqualError(t, err, "bad Content-Format 70000: out of range")
+
+	_, err = c.FindByType(1.5)
+	assert.EqualError(t, err, "unsupported type float64 for media type, want string or Content-Format")
+
+	_, err = c.FindByType("not a media type")
+	assert.ErrorContains(t, err, "bad media type")
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	cose "github.com/veraison/go-cose"
+)
+
+// Builder constructs a collection CMW fluently, e.g.:
+//
+//	b, err := cmw.Build().
+//		Type("tag:example.com,2025:bundle").
+//		Monad("ev", "application/eat+cwt", ev, cmw.Evidence).
+//		Collection("sub", func(b *cmw.Builder) {
+//			b.Monad(1, uint16(30001), v)
+//		}).
+//		CBOR()
+//
+// Errors are accumulated, together with the key path of the offending item,
+// and reported by Done or by any of the serialization methods.  A Builder is
+// not safe for concurrent use (see CollectionBuilder).
+type Builder struct {
+	path []any
+	c    *CMW
+	errs *[]error
+}
+
+// Build starts building a collection CMW
+func Build() *Builder {
+	c, _ := NewCollection("")
+	return &Builder{c: c, errs: new([]error)}
+}
+
+// Type sets the collection's __cmwc_t
+func (o *Builder) Type(cmwct string) *Builder {
+	if err := validateCollectionType(cmwct); err != nil {
+		o.fail(nil, err)
+		return o
+	}
+	o.c.ctyp = cmwct
+	return o
+}
+
+// Monad adds a record monad under key.  Integer keys of any Go integer type
+// are accepted.
+func (o *Builder) Monad(key any, mediaType any, value []byte, indicators ...Indicator) *Builder {
+	m, err := NewMonad(mediaType, value, indicators...)
+	if err != nil {
+		o.fail(key, err)
+		return o
+	}
+	return o.Item(key, m)
+}
+
+// Tag adds a CBOR tag monad under key
+func (o *Builder) Tag(key any, mediaType any, value []byte) *Builder {
+	m, err := NewMonad(mediaType, value)
+	if err != nil {
+		o.fail(key, err)
+		return o
+	}
+	m.UseCBORTagFormat()
+	return o.Item(key, m)
+}
+
+// Item adds an existing CMW under key
+func (o *Builder) Item(key any, node *CMW) *Builder {
+	k, err := builderKey(key)
+	if err != nil {
+		o.fail(key, err)
+		return o
+	}
+
+	if _, found := o.c.cmap[k]; found {
+		o.fail(k, errors.New("duplicate key"))
+		return o
+	}
+
+	if err := o.c.AddCollectionItem(k, node); err != nil {
+		o.fail(k, err)
+	}
+
+	return o
+}
+
+// Collection adds a nested collection under key, whose contents are supplied
+// by fn using the Builder it is passed
+func (o *Builder) Collection(key any, fn func(b *Builder)) *Builder {
+	k, err := builderKey(key)
+	if err != nil {
+		o.fail(key, err)
+		return o
+	}
+
+	c, _ := NewCollection("")
+
+	sub := &Builder{
+		path: append(append([]any(nil), o.path...), k),
+		c:    c,
+		errs: o.errs,
+	}
+
+	fn(sub)
+
+	return o.Item(k, sub.c)
+}
+
+// Done validates the collection and returns it, or the accumulated errors
+func (o *Builder) Done() (*CMW, error) {
+	if len(*o.errs) > 0 {
+		return nil, errors.Join(*o.errs...)
+	}
+
+	if err := o.c.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", formatPath(o.path), err)
+	}
+
+	return o.c, nil
+}
+
+// CBOR returns the CBOR serialization of the collection
+func (o *Builder) CBOR() ([]byte, error) {
+	c, err := o.Done()
+	if err != nil {
+		return nil, err
+	}
+	return c.MarshalCBOR()
+}
+
+// JSON returns the JSON serialization of the collection
+func (o *Builder) JSON() ([]byte, error) {
+	c, err := o.Done()
+	if err != nil {
+		return nil, err
+	}
+	return c.MarshalJSON()
+}
+
+// EDN returns the annotated CBOR diagnostic notation of the collection
+func (o *Builder) EDN() ([]byte, error) {
+	c, err := o.Done()
+	if err != nil {
+		return nil, err
+	}
+	return c.MarshalEDN()
+}
+
+// SignCBOR returns the collection as a signed-cbor-cmw (see CMW.SignCBOR)
+func (o *Builder) SignCBOR(signer cose.Signer) ([]byte, error) {
+	c, err := o.Done()
+	if err != nil {
+		return nil, err
+	}
+	return c.SignCBOR(signer)
+}
+
+// SignJSON returns the collection as a signed-json-cmw (see CMW.SignJSON)
+func (o *Builder) SignJSON(alg jwa.SignatureAlgorithm, key any) ([]byte, error) {
+	c, err := o.Done()
+	if err != nil {
+		return nil, err
+	}
+	return c.SignJSON(alg, key)
+}
+
+func (o *Builder) fail(key any, err error) {
+	p := o.path
+	if key != nil {
+		p = append(append([]any(nil), p...), key)
+	}
+	*o.errs = append(*o.errs, fmt.Errorf("%s: %w", formatPath(p), err))
+}
+
+// builderKey converts Go integers to the key types used by decoded collections
+// and checks the resulting key, so that it can be safely used to index the
+// collection map
+func builderKey(key any) (any, error) {
+	k := normalizeInt(key)
+
+	if err := validateCollectionKey(k); err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+
+	return k, nil
+}
+
+func normalizeInt(key any) any {
+	var i int64
+
+	switch v := key.(type) {
+	case int:
+		i = int64(v)
+	case int8:
+		i = int64(v)
+	case int16:
+		i = int64(v)
+	case int32:
+		i = int64(v)
+	case int64:
+		i = v
+	case uint:
+		return uint64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	default:
+		return key
+	}
+
+	if i >= 0 {
+		return uint64(i)
+	}
+
+	return i
+}
+
+// formatPath renders a list of collection keys as, e.g., /"a"/1
+func formatPath(p []any) string {
+	s := make([]string, len(p))
+	for i, k := range p {
+		s[i] = formatKey(k)
+	}
+	return "/" + strings.Join(s, "/")
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cose "github.com/veraison/go-cose"
+)
+
+func Test_Build_ok(t *testing.T) {
+	b := Build().
+		Type("tag:ietf.org,2024:X").
+		Collection("murmurless", func(b *Builder) {
+			b.Type("tag:ietf.org,2024:Y").
+				Monad("polyscopic", "application/eat-ucs+json", []byte(`{"eat_nonce": ...}`), AttestationResults)
+		}).
+		Monad("bretwaldadom", "application/eat-ucs+cbor", []byte{0xa1, 0x0a}).
+		Monad("photoelectrograph", "application/eat-ucs+cbor", []byte{0x82, 0x78, 0x18}, ReferenceValues, Endorsements)
+
+	actual, err := b.CBOR()
+	require.NoError(t, err)
+	assert.Equal(t, mustMarshalCBOR(t, makeCMWCollection()), actual)
+
+	expected, err := makeCMWCollection().MarshalJSON()
+	require.NoError(t, err)
+
+	actual, err = b.JSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(actual))
+
+	c, err := b.Done()
+	require.NoError(t, err)
+	assert.True(t, c.Equal(*makeCMWCollection()))
+}
+
+func Test_Build_integer_keys_and_tags(t *testing.T) {
+	c, err := Build().
+		Tag(2, uint16(30001), []byte{0x23, 0x47, 0xda, 0x55}).
+		Monad(int8(-1), "application/vnd.a", []byte{0x61}).
+		Done()
+	require.NoError(t, err)
+
+	tag, err := c.GetCollectionItem(uint64(2))
+	require.NoError(t, err)
+	assert.Equal(t, FormatCBORTag, tag.GetFormat())
+
+	_, err = c.GetCollectionItem(int64(-1))
+	assert.NoError(t, err)
+
+	var decoded CMW
+	require.NoError(t, decoded.UnmarshalCBOR(mustMarshalCBOR(t, c)))
+	assert.True(t, c.Equal(decoded, IgnoreFormat(), NormalizeTypes()))
+
+	edn, err := Build().Tag(2, uint16(30001), []byte{0x23, 0x47, 0xda, 0x55}).EDN()
+	require.NoError(t, err)
+	assert.Contains(t, string(edn), "h'2347da55'")
+}
+
+func Test_Build_accumulates_errors(t *testing.T) {
+	_, err := Build().
+		Type("not a URI").
+		Monad("ev", "not a media type", []byte{0x01}).
+		Monad("empty", "application/vnd.a", nil).
+		Collection("sub", func(b *Builder) {
+			b.Monad(1, 3.14, []byte{0x01}).
+				Collection("deeper", func(b *Builder) {
+					b.Monad(CmwCType, "application/vnd.a", []byte{0x01})
+				})
+		}).
+		Monad("dup", "application/vnd.a", []byte{0x01}).
+		Monad("dup", "application/vnd.a", []byte{0x01}).
+		Item(1.5, makeCMWCollection()).
+		Item([]byte("k"), makeCMWCollection()).
+		CBOR()
+
+	assert.EqualError(t, err, `/: invalid collection type: "not a URI".  URI is not absolute
+/"ev": bad media type: mime: expected slash after first token
+/"empty": empty value
+/"sub"/1: unsupported type float64 for CMW type
+/"sub"/"deeper"/"__cmwc_t": invalid key: bad collection key: __cmwc_t is reserved
+/"dup": duplicate key
+/1.5: invalid key: unknown collection key type: want string or int, got float64
+/[107]: invalid key: unknown collection key type: want string or int, got []uint8`)
+}
+
+func Test_Build_validation(t *testing.T) {
+	_, err := Build().Done()
+	assert.EqualError(t, err, "/: empty CMW collection")
+
+	_, err = Build().
+		Monad("a", "application/vnd.a", []byte{0x01}).
+		Collection("empty", func(*Builder) {}).
+		JSON()
+	assert.EqualError(t, err, `/: invalid collection at key "empty": empty CMW collection`)
+}
+
+func Test_Build_SignCBOR(t *testing.T) {
+	signer, verifier, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+
+	signed, err := Build().Monad("a", "application/vnd.a", []byte{0x01}).SignCBOR(signer)
+	require.NoError(t, err)
+
+	var c CMW
+	require.NoError(t, c.VerifyCBOR(verifier, signed))
+
+	_, err = Build().SignCBOR(signer)
+	assert.EqualError(t, err, "/: empty CMW collection")
+}
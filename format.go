@@ -0,0 +1,311 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// previewLen is the number of value bytes shown when printing a monad
+const previewLen = 16
+
+const redactedValue = "<redacted>"
+
+// Format implements fmt.Formatter.  The %v and %s verbs print a compact,
+// single-line summary of the CMW; %+v prints a tree with one line per node.
+// Monad values are shown as a truncated preview: hex for CBOR formats,
+// base64url for JSON formats.  Use Redact to hide the values.
+func (o CMW) Format(f fmt.State, verb rune) { formatCMW(f, verb, o, false) }
+
+// LogValue implements slog.LogValuer
+func (o CMW) LogValue() slog.Value { return cmwLogValue(o, false) }
+
+// Redacted wraps a CMW so that it is printed and logged without monad values
+type Redacted struct {
+	c CMW
+}
+
+// Redact returns a view of c that can be passed to fmt or log/slog without
+// leaking the (possibly sensitive) values of its monads, e.g., when logging
+// in production
+func Redact(c CMW) Redacted { return Redacted{c} }
+
+// Format implements fmt.Formatter.  See CMW.Format.
+func (o Redacted) Format(f fmt.State, verb rune) { formatCMW(f, verb, o.c, true) }
+
+// LogValue implements slog.LogValuer
+func (o Redacted) LogValue() slog.Value { return cmwLogValue(o.c, true) }
+
+func formatCMW(f fmt.State, verb rune, c CMW, redact bool) {
+	var b strings.Builder
+
+	switch verb {
+	case 'v', 's', 'q':
+		if verb == 'v' && f.Flag('+') {
+			writeTree(&b, c, "", 0, redact)
+		} else {
+			writeCompact(&b, c, redact)
+		}
+	default:
+		fmt.Fprintf(f, "%%!%c(cmw.CMW)", verb)
+		return
+	}
+
+	writeFormatted(f, verb, b.String())
+}
+
+// writeFormatted honours %q and the width/alignment flags
+func writeFormatted(f fmt.State, verb rune, s string) {
+	if verb == 'q' {
+		s = strconv.Quote(s)
+	}
+
+	if w, n := f.Width(); n && utf8.RuneCountInString(s) < w {
+		pad := strings.Repeat(" ", w-utf8.RuneCountInString(s))
+		if f.Flag('-') {
+			s += pad
+		} else {
+			s = pad + s
+		}
+	}
+
+	_, _ = f.Write([]byte(s))
+}
+
+func writeCompact(b *strings.Builder, c CMW, redact bool) {
+	switch c.kind {
+	case KindMonad:
+		writeMonad(b, c, redact)
+	case KindCollection:
+		writeCollectionHeader(b, c)
+		b.WriteString(" {")
+		for i, m := range c.collection.getMeta() {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(b, "%s: ", formatKey(m.Key))
+			writeCompact(b, c.collection.cmap[m.Key], redact)
+		}
+		b.WriteString("}")
+	default:
+		b.WriteString("unknown CMW")
+	}
+}
+
+func writeTree(b *strings.Builder, c CMW, label string, depth int, redact bool) {
+	if depth > 0 {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(strings.Repeat("  ", depth))
+
+	if label != "" {
+		b.WriteString(label + ": ")
+	}
+
+	switch c.kind {
+	case KindMonad:
+		writeMonad(b, c, redact)
+	case KindCollection:
+		writeCollectionHeader(b, c)
+		for _, m := range c.collection.getMeta() {
+			writeTree(b, c.collection.cmap[m.Key], formatKey(m.Key), depth+1, redact)
+		}
+	default:
+		b.WriteString("unknown CMW")
+	}
+}
+
+func writeMonad(b *strings.Builder, c CMW, redact bool) {
+	fmt.Fprintf(b, "monad (%s) %s", c.monad.format, c.monad.typ)
+
+	if !c.monad.ind.Empty() {
+		fmt.Fprintf(b, " [%s]", c.monad.ind)
+	}
+
+	fmt.Fprintf(b, ", %d bytes: %s", c.monad.valueSize(), monadPreview(c.monad, redact))
+}
+
+func writeCollectionHeader(b *strings.Builder, c CMW) {
+	fmt.Fprintf(b, "collection (%s)", c.collection.format)
+
+	if c.collection.ctyp != "" {
+		b.WriteString(" " + c.collection.ctyp)
+	}
+}
+
+func formatKey(k any) string {
+	if s, ok := k.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(k)
+}
+
+const streamedValue = "<streamed>"
+
+func monadPreview(m monad, redact bool) string {
+	if m.src != nil && !redact {
+		return streamedValue
+	}
+	return valuePreview(m.val, m.format, redact)
+}
+
+// valuePreview renders (up to previewLen bytes of) v the way it appears in
+// the serialized CMW
+func valuePreview(v []byte, format Format, redact bool) string {
+	if redact {
+		return redactedValue
+	}
+
+	var ellipsis string
+	if len(v) > previewLen {
+		v, ellipsis = v[:previewLen], "…"
+	}
+
+	switch format {
+	case FormatJSONRecord, FormatJSONCollection:
+		return base64.RawURLEncoding.EncodeToString(v) + ellipsis
+	default:
+		return "h'" + hex.EncodeToString(v) + ellipsis + "'"
+	}
+}
+
+func cmwLogValue(c CMW, redact bool) slog.Value {
+	attrs := []slog.Attr{
+		slog.String("kind", c.kind.String()),
+		slog.String("format", c.GetFormat().String()),
+	}
+
+	switch c.kind {
+	case KindMonad:
+		attrs = append(attrs,
+			slog.Any("type", c.monad.typ),
+			slog.Any("indicators", c.monad.ind),
+			slog.Int64("length", c.monad.valueSize()),
+			slog.String("value", monadPreview(c.monad, redact)),
+		)
+	case KindCollection:
+		if c.collection.ctyp != "" {
+			attrs = append(attrs, slog.String("type", c.collection.ctyp))
+		}
+		items := make([]slog.Attr, 0, len(c.collection.cmap))
+		for _, m := range c.collection.getMeta() {
+			items = append(items, slog.Attr{
+				Key:   fmt.Sprint(m.Key),
+				Value: cmwLogValue(c.collection.cmap[m.Key], redact),
+			})
+		}
+		attrs = append(attrs, slog.Attr{Key: "items", Value: slog.GroupValue(items...)})
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// Format implements fmt.Formatter.  The %v and %s verbs print the media type,
+// resolving Content-Format IDs and CBOR Tag numbers through the registry;
+// %+v also shows the number the media type was resolved from.
+func (o Type) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's', 'q':
+	default:
+		fmt.Fprintf(f, "%%!%c(cmw.Type=%s)", verb, o)
+		return
+	}
+
+	s := o.String()
+
+	if verb == 'v' && f.Flag('+') {
+		switch v := o.val.(type) {
+		case uint16:
+			s = fmt.Sprintf("%s (C-F %d)", s, v)
+		case uint64:
+			if cf, err := CF(v); err == nil {
+				s = fmt.Sprintf("%s (TN %d, C-F %d)", s, v, cf)
+			}
+		}
+	}
+
+	writeFormatted(f, verb, s)
+}
+
+// LogValue implements slog.LogValuer
+func (o Type) LogValue() slog.Value {
+	switch v := o.val.(type) {
+	case uint16:
+		return slog.GroupValue(
+			slog.String("media-type", o.String()),
+			slog.Uint64("content-format", uint64(v)),
+		)
+	case uint64:
+		cf, _ := CF(v)
+		return slog.GroupValue(
+			slog.String("media-type", o.String()),
+			slog.Uint64("content-format", uint64(cf)),
+			slog.Uint64("tag-number", v),
+		)
+	default:
+		return slog.StringValue(o.String())
+	}
+}
+
+// Format implements fmt.Formatter.  The %v and %s verbs print the indicator
+// names (or "none"); %+v prefixes them with the numeric value.  Numeric verbs
+// (%d, %x, %o, %b) print the bit map.
+func (o Indicator) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'd', 'x', 'X', 'o', 'b':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), uint(o))
+		return
+	case 'v', 's', 'q':
+	default:
+		fmt.Fprintf(f, "%%!%c(cmw.Indicator=%d)", verb, uint(o))
+		return
+	}
+
+	s := o.String()
+	if o.Empty() {
+		s = "none"
+	}
+
+	if verb == 'v' && f.Flag('+') {
+		s = fmt.Sprintf("%d [%s]", uint(o), s)
+	}
+
+	writeFormatted(f, verb, s)
+}
+
+// LogValue implements slog.LogValuer
+func (o Indicator) LogValue() slog.Value {
+	if o.Empty() {
+		return slog.StringValue("none")
+	}
+	return slog.StringValue(o.String())
+}
+
+// Format implements fmt.Formatter.  The %v and %s verbs print the key and
+// kind of the collection item.
+func (o Meta) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's', 'q':
+	default:
+		fmt.Fprintf(f, "%%!%c(cmw.Meta)", verb)
+		return
+	}
+
+	writeFormatted(f, verb, fmt.Sprintf("%s: %s", formatKey(o.Key), o.Kind))
+}
+
+// LogValue implements slog.LogValuer
+func (o Meta) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Any("key", o.Key),
+		slog.String("kind", o.Kind.String()),
+	)
+}
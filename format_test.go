@@ -0,0 +1,117 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeFormatTestCollection(t *testing.T) *CMW {
+	var c CMW
+	require.NoError(t, c.UnmarshalJSON([]byte(`{
+		"__cmwc_t": "tag:example.com,2025:x",
+		"a": ["application/eat-ucs+json", "eyJlYXRfbm9uY2UiOiAiMDEyMzQ1Njc4OWFiY2RlZiJ9", 8],
+		"b": { "__cmwc_t": "1.2.3.4", "c": [10571, "oQo"] }
+	}`)))
+	return &c
+}
+
+func Test_CMW_Format(t *testing.T) {
+	c := makeFormatTestCollection(t)
+
+	tag := mustNewMonad(t, uint16(10571), []byte{0xa1, 0x0a}, Evidence)
+	tag.UseCBORTagFormat()
+
+	tests := []struct {
+		name     string
+		format   string
+		arg      any
+		expected string
+	}{
+		{
+			"compact collection", "%v", c,
+			`collection (JSON collection) tag:example.com,2025:x {"a": monad (JSON record) application/eat-ucs+json [attestation results], 33 bytes: eyJlYXRfbm9uY2UiOiAiMA…, "b": collection (JSON collection) 1.2.3.4 {"c": monad (JSON record) application/ce+cbor, 2 bytes: oQo}}`,
+		},
+		{
+			"tree", "%+v", *c,
+			"collection (JSON collection) tag:example.com,2025:x\n" +
+				`  "a": monad (JSON record) application/eat-ucs+json [attestation results], 33 bytes: eyJlYXRfbm9uY2UiOiAiMA…` + "\n" +
+				"  \"b\": collection (JSON collection) 1.2.3.4\n" +
+				`    "c": monad (JSON record) application/ce+cbor, 2 bytes: oQo`,
+		},
+		{
+			"redacted tree", "%+v", Redact(*c),
+			"collection (JSON collection) tag:example.com,2025:x\n" +
+				`  "a": monad (JSON record) application/eat-ucs+json [attestation results], 33 bytes: <redacted>` + "\n" +
+				"  \"b\": collection (JSON collection) 1.2.3.4\n" +
+				`    "c": monad (JSON record) application/ce+cbor, 2 bytes: <redacted>`,
+		},
+		{"CBOR tag", "%s", tag, "monad (CBOR tag) application/ce+cbor [evidence], 2 bytes: h'a10a'"},
+		{"quoted", "%q", Redact(*tag), `"monad (CBOR tag) application/ce+cbor [evidence], 2 bytes: <redacted>"`},
+		{"unknown", "%v", CMW{}, "unknown CMW"},
+		{"width", "%-13v|", CMW{}, "unknown CMW  |"},
+		{"bad verb", "%d", CMW{}, "%!d(cmw.CMW)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fmt.Sprintf(tt.format, tt.arg))
+		})
+	}
+}
+
+func Test_Type_Indicator_Meta_Format(t *testing.T) {
+	tests := []struct {
+		format   string
+		arg      any
+		expected string
+	}{
+		{"%v", Type{"application/vnd.a"}, "application/vnd.a"},
+		{"%+v", Type{uint16(10571)}, "application/ce+cbor (C-F 10571)"},
+		{"%+v", Type{uint64(1668557429)}, "application/ce+cbor (TN 1668557429, C-F 10571)"},
+		{"%q", Type{uint16(30001)}, `"30001"`},
+		{"%v", Indicator(Evidence | Endorsements), "endorsements, evidence"},
+		{"%v", Indicator(IndicatorNone), "none"},
+		{"%+v", Indicator(Evidence), "4 [evidence]"},
+		{"%#02x", Indicator(Evidence), "0x04"},
+		{"%v", Meta{"a", KindMonad}, `"a": monad`},
+		{"%v", Meta{uint64(1), KindCollection}, "1: collection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fmt.Sprintf(tt.format, tt.arg))
+		})
+	}
+}
+
+func Test_CMW_LogValue(t *testing.T) {
+	c := makeFormatTestCollection(t)
+
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	logger.Info("plain", "cmw", c)
+	logger.Info("redacted", "cmw", Redact(*c))
+
+	expected := `{"msg":"plain","cmw":{"kind":"collection","format":"JSON collection","type":"tag:example.com,2025:x","items":{"a":{"kind":"monad","format":"JSON record","type":"application/eat-ucs+json","indicators":"attestation results","length":33,"value":"eyJlYXRfbm9uY2UiOiAiMA…"},"b":{"kind":"collection","format":"JSON collection","type":"1.2.3.4","items":{"c":{"kind":"monad","format":"JSON record","type":{"media-type":"application/ce+cbor","content-format":10571},"indicators":"none","length":2,"value":"oQo"}}}}}}
+{"msg":"redacted","cmw":{"kind":"collection","format":"JSON collection","type":"tag:example.com,2025:x","items":{"a":{"kind":"monad","format":"JSON record","type":"application/eat-ucs+json","indicators":"attestation results","length":33,"value":"<redacted>"},"b":{"kind":"collection","format":"JSON collection","type":"1.2.3.4","items":{"c":{"kind":"monad","format":"JSON record","type":{"media-type":"application/ce+cbor","content-format":10571},"indicators":"none","length":2,"value":"<redacted>"}}}}}}
+`
+
+	assert.Equal(t, expected, buf.String())
+}
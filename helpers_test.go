@@ -0,0 +1,19 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustNewMonad(t *testing.T, mediaType any, value []byte, indicators ...Indicator) *CMW {
+	t.Helper()
+
+	m, err := NewMonad(mediaType, value, indicators...)
+	require.NoError(t, err)
+
+	return m
+}
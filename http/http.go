@@ -0,0 +1,302 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package http provides helpers for exchanging CMWs over HTTP: decoding
+// request bodies according to their Content-Type, encoding responses according
+// to the client's Accept header, and a middleware that makes the decoded CMW
+// available to downstream handlers via the request context.
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/veraison/cmw"
+)
+
+const (
+	MediaTypeCMWJSON = "application/cmw+json"
+	MediaTypeCMWCBOR = "application/cmw+cbor"
+
+	// ParamCollectionType is the optional media type parameter carrying the
+	// __cmwc_t of a Collection CMW
+	ParamCollectionType = "cmwc_t"
+)
+
+// DefaultMaxBodySize is the default limit on the size of request bodies
+const DefaultMaxBodySize = 1 << 20
+
+type options struct {
+	maxBodySize  int64
+	deserializer []cmw.DeserializeOption
+}
+
+// Option configures DecodeRequest and Middleware
+type Option func(*options)
+
+// WithMaxBodySize sets the maximum accepted request body size in bytes
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) { o.maxBodySize = n }
+}
+
+// WithDeserializeOptions sets the options passed to cmw.Deserialize when the
+// request does not carry a CMW media type, e.g., to accept signed CMWs
+func WithDeserializeOptions(opts ...cmw.DeserializeOption) Option {
+	return func(o *options) { o.deserializer = opts }
+}
+
+func newOptions(opts []Option) *options {
+	o := options{maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &o
+}
+
+// DecodeRequest decodes the body of r into a CMW.  The decoder is selected
+// using the Content-Type header: application/cmw+json and application/cmw+cbor
+// are decoded as such, whereas a missing or application/octet-stream
+// Content-Type causes the format to be sniffed.  If the media type has a
+// cmwc_t parameter, the CMW must be a collection of that type.  Errors are
+// returned as *Problem.
+func DecodeRequest(r *http.Request, opts ...Option) (*cmw.CMW, error) {
+	o := newOptions(opts)
+
+	mt, params, err := parseContentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, newProblem(http.StatusUnsupportedMediaType, "%v", err)
+	}
+
+	if r.Body == nil {
+		return nil, newProblem(http.StatusBadRequest, "empty body")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, o.maxBodySize+1))
+	if err != nil {
+		return nil, newProblem(http.StatusBadRequest, "reading body: %v", err)
+	}
+
+	if int64(len(body)) > o.maxBodySize {
+		return nil, newProblem(http.StatusRequestEntityTooLarge, "body exceeds %d bytes", o.maxBodySize)
+	}
+
+	if len(body) == 0 {
+		return nil, newProblem(http.StatusBadRequest, "empty body")
+	}
+
+	var c cmw.CMW
+
+	switch mt {
+	case MediaTypeCMWJSON:
+		err = c.UnmarshalJSON(body)
+	case MediaTypeCMWCBOR:
+		err = c.UnmarshalCBOR(body)
+	default:
+		err = c.Deserialize(body, o.deserializer...)
+	}
+
+	if err != nil {
+		return nil, newProblem(http.StatusBadRequest, "decoding CMW: %v", err)
+	}
+
+	if ctyp, ok := params[ParamCollectionType]; ok {
+		if err := checkCollectionType(&c, ctyp); err != nil {
+			return nil, newProblem(http.StatusBadRequest, "%v", err)
+		}
+	}
+
+	return &c, nil
+}
+
+func parseContentType(ct string) (string, map[string]string, error) {
+	if ct == "" {
+		return "", nil, nil
+	}
+
+	mt, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing Content-Type: %w", err)
+	}
+
+	switch mt {
+	case MediaTypeCMWJSON, MediaTypeCMWCBOR, "application/octet-stream":
+		return mt, params, nil
+	default:
+		return "", nil, fmt.Errorf("want %s or %s, got %s", MediaTypeCMWJSON, MediaTypeCMWCBOR, mt)
+	}
+}
+
+func checkCollectionType(c *cmw.CMW, want string) error {
+	got, err := c.GetCollectionType()
+	if err != nil {
+		return fmt.Errorf("%s parameter set on a non-collection CMW", ParamCollectionType)
+	}
+
+	if got != want {
+		return fmt.Errorf("collection type mismatch: %s parameter %q, __cmwc_t %q", ParamCollectionType, want, got)
+	}
+
+	return nil
+}
+
+// WriteResponse encodes c using the media type preferred by the client
+// according to the Accept header of r.  Without an Accept header, or when the
+// client has no preference, the native format of c is used.  If none of the
+// CMW media types is acceptable, a 406 problem details response is sent
+// instead and a *Problem is returned.
+func WriteResponse(w http.ResponseWriter, r *http.Request, c *cmw.CMW, status int) error {
+	mt, err := negotiate(r.Header.Get("Accept"), nativeMediaType(c))
+	if err != nil {
+		WriteProblem(w, err.(*Problem))
+		return err
+	}
+
+	var b []byte
+
+	switch mt {
+	case MediaTypeCMWJSON:
+		b, err = c.MarshalJSON()
+	case MediaTypeCMWCBOR:
+		b, err = c.MarshalCBOR()
+	}
+
+	if err != nil {
+		p := newProblem(http.StatusInternalServerError, "encoding CMW as %s: %v", mt, err)
+		WriteProblem(w, p)
+		return p
+	}
+
+	if ctyp, err := c.GetCollectionType(); err == nil && ctyp != "" {
+		mt = mime.FormatMediaType(mt, map[string]string{ParamCollectionType: ctyp})
+	}
+
+	w.Header().Set("Content-Type", mt)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("writing response: %w", err)
+	}
+
+	return nil
+}
+
+func nativeMediaType(c *cmw.CMW) string {
+	switch c.GetFormat() {
+	case cmw.FormatJSONRecord, cmw.FormatJSONCollection:
+		return MediaTypeCMWJSON
+	default:
+		return MediaTypeCMWCBOR
+	}
+}
+
+type acceptRange struct {
+	mt string
+	q  float64
+}
+
+// negotiate picks the CMW media type with the highest q-value in accept,
+// breaking ties in favour of native
+func negotiate(accept, native string) (string, error) {
+	if strings.TrimSpace(accept) == "" {
+		return native, nil
+	}
+
+	var ranges []acceptRange
+
+	for _, s := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(s)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mt, q})
+	}
+
+	// more specific ranges take precedence over wildcards
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return strings.Count(ranges[i].mt, "*") < strings.Count(ranges[j].mt, "*")
+	})
+
+	best, bestQ := "", 0.0
+
+	for _, candidate := range []string{native, otherMediaType(native)} {
+		q := qualityOf(candidate, ranges)
+		if q > bestQ {
+			best, bestQ = candidate, q
+		}
+	}
+
+	if best == "" {
+		return "", newProblem(http.StatusNotAcceptable, "want %s or %s in Accept, got %q", MediaTypeCMWJSON, MediaTypeCMWCBOR, accept)
+	}
+
+	return best, nil
+}
+
+func otherMediaType(mt string) string {
+	if mt == MediaTypeCMWJSON {
+		return MediaTypeCMWCBOR
+	}
+	return MediaTypeCMWJSON
+}
+
+// qualityOf returns the q-value of the first (i.e., most specific) range
+// matching mt
+func qualityOf(mt string, ranges []acceptRange) float64 {
+	typ, _, _ := strings.Cut(mt, "/")
+
+	for _, r := range ranges {
+		if r.mt == mt || r.mt == typ+"/*" || r.mt == "*/*" {
+			return r.q
+		}
+	}
+
+	return 0
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying c
+func NewContext(ctx context.Context, c *cmw.CMW) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the CMW stored in ctx by Middleware (or NewContext), if
+// any
+func FromContext(ctx context.Context) (*cmw.CMW, bool) {
+	c, ok := ctx.Value(contextKey{}).(*cmw.CMW)
+	return c, ok && c != nil
+}
+
+// Middleware decodes the request body using DecodeRequest and passes the
+// resulting CMW to next via the request context (see FromContext).  Requests
+// that cannot be decoded are answered with problem details and are not
+// forwarded.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := DecodeRequest(r, opts...)
+		if err != nil {
+			var p *Problem
+			if !errors.As(err, &p) {
+				p = newProblem(http.StatusBadRequest, "%v", err)
+			}
+			WriteProblem(w, p)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), c)))
+	})
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/veraison/cmw"
+)
+
+const testCollectionJSON = `{"__cmwc_t":"tag:example.com,2025:x","a":["application/vnd.a","YQ",4]}`
+
+func newRequest(t *testing.T, body, contentType string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/cmw", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func mustReadFile(t *testing.T, fname string) []byte {
+	b, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	return b
+}
+
+func Test_DecodeRequest_ok(t *testing.T) {
+	cborCollection := string(mustReadFile(t, "../testdata/collection-cbor-ok.cbor"))
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		format      cmw.Format
+	}{
+		{"JSON", testCollectionJSON, MediaTypeCMWJSON, cmw.FormatJSONCollection},
+		{"JSON with cmwc_t", testCollectionJSON, `application/cmw+json; cmwc_t="tag:example.com,2025:x"`, cmw.FormatJSONCollection},
+		{"CBOR", cborCollection, MediaTypeCMWCBOR, cmw.FormatCBORCollection},
+		{"sniffed JSON", testCollectionJSON, "", cmw.FormatJSONCollection},
+		{"sniffed CBOR", cborCollection, "application/octet-stream", cmw.FormatCBORCollection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := DecodeRequest(newRequest(t, tt.body, tt.contentType))
+			require.NoError(t, err)
+			assert.Equal(t, tt.format, c.GetFormat())
+		})
+	}
+}
+
+func Test_DecodeRequest_fail(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		opts        []Option
+		status      int
+		detail      string
+	}{
+		{"unsupported media type", testCollectionJSON, "application/json", nil, http.StatusUnsupportedMediaType, "want application/cmw+json or application/cmw+cbor, got application/json"},
+		{"bad media type", testCollectionJSON, "application/", nil, http.StatusUnsupportedMediaType, "parsing Content-Type: mime: expected token after slash"},
+		{"empty body", "", MediaTypeCMWJSON, nil, http.StatusBadRequest, "empty body"},
+		{"too large", testCollectionJSON, MediaTypeCMWJSON, []Option{WithMaxBodySize(10)}, http.StatusRequestEntityTooLarge, "body exceeds 10 bytes"},
+		{"wrong format", testCollectionJSON, MediaTypeCMWCBOR, nil, http.StatusBadRequest, "decoding CMW: want CBOR map, CBOR array or CBOR Tag start symbols, got: 0x7b"},
+		{"cmwc_t mismatch", testCollectionJSON, "application/cmw+json; cmwc_t=1.2.3", nil, http.StatusBadRequest, `collection type mismatch: cmwc_t parameter "1.2.3", __cmwc_t "tag:example.com,2025:x"`},
+		{"cmwc_t on monad", `["application/vnd.a","YQ"]`, "application/cmw+json; cmwc_t=1.2.3", nil, http.StatusBadRequest, "cmwc_t parameter set on a non-collection CMW"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeRequest(newRequest(t, tt.body, tt.contentType), tt.opts...)
+			var p *Problem
+			require.ErrorAs(t, err, &p)
+			assert.Equal(t, tt.status, p.Status)
+			assert.Equal(t, tt.detail, p.Detail)
+			assert.Equal(t, http.StatusText(tt.status), p.Title)
+		})
+	}
+}
+
+func Test_WriteResponse(t *testing.T) {
+	var jc cmw.CMW
+	require.NoError(t, jc.UnmarshalJSON([]byte(testCollectionJSON)))
+
+	var cc cmw.CMW
+	require.NoError(t, cc.UnmarshalCBOR(mustReadFile(t, "../testdata/collection-cbor-ok.cbor")))
+
+	tests := []struct {
+		name        string
+		c           *cmw.CMW
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"native JSON", &jc, "", http.StatusOK, `application/cmw+json; cmwc_t="tag:example.com,2025:x"`},
+		{"native CBOR", &cc, "", http.StatusOK, MediaTypeCMWCBOR},
+		{"wildcard", &cc, "*/*", http.StatusOK, MediaTypeCMWCBOR},
+		{"explicit CBOR", &jc, "application/cmw+cbor", http.StatusOK, `application/cmw+cbor; cmwc_t="tag:example.com,2025:x"`},
+		{"q-values", &jc, "application/cmw+json;q=0.5, application/cmw+cbor;q=0.9", http.StatusOK, `application/cmw+cbor; cmwc_t="tag:example.com,2025:x"`},
+		{"specific beats wildcard", &jc, "application/*;q=0.9, application/cmw+json;q=0.1", http.StatusOK, `application/cmw+cbor; cmwc_t="tag:example.com,2025:x"`},
+		{"not acceptable", &jc, "text/html", http.StatusNotAcceptable, MediaTypeProblemJSON},
+		{"excluded", &jc, "application/cmw+json;q=0, application/cmw+cbor;q=0", http.StatusNotAcceptable, MediaTypeProblemJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/cmw", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			err := WriteResponse(w, r, tt.c, http.StatusOK)
+			assert.Equal(t, tt.status == http.StatusOK, err == nil)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var back cmw.CMW
+			require.NoError(t, back.Deserialize(w.Body.Bytes()))
+		})
+	}
+}
+
+func Test_WriteResponse_encoding_error(t *testing.T) {
+	// integer keys cannot be serialized as JSON
+	var c cmw.CMW
+	require.NoError(t, c.UnmarshalCBOR(mustReadFile(t, "../testdata/collection-cbor-ok.cbor")))
+
+	r := httptest.NewRequest(http.MethodGet, "/cmw", nil)
+	r.Header.Set("Accept", MediaTypeCMWJSON)
+	w := httptest.NewRecorder()
+
+	err := WriteResponse(w, r, &c, http.StatusOK)
+	assert.ErrorContains(t, err, "encoding CMW as application/cmw+json")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func Test_Middleware(t *testing.T) {
+	var got *cmw.CMW
+
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, ok := FromContext(r.Context())
+		require.True(t, ok)
+		got = c
+		require.NoError(t, WriteResponse(w, r, c, http.StatusCreated))
+	}))
+
+	// ok
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest(t, testCollectionJSON, MediaTypeCMWJSON))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	require.NotNil(t, got)
+	assert.Equal(t, cmw.KindCollection, got.GetKind())
+	assert.JSONEq(t, testCollectionJSON, w.Body.String())
+
+	// bad request
+	got = nil
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest(t, "{", MediaTypeCMWJSON))
+	assert.Nil(t, got)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, MediaTypeProblemJSON, w.Header().Get("Content-Type"))
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+	assert.Equal(t, "Bad Request", p.Title)
+	assert.Contains(t, p.Detail, "decoding CMW")
+}
+
+func Test_FromContext_empty(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, ok := FromContext(r.Context())
+	assert.False(t, ok)
+}
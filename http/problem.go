@@ -0,0 +1,52 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// MediaTypeProblemJSON is the media type of RFC 9457 problem details
+const MediaTypeProblemJSON = "application/problem+json"
+
+// Problem is an RFC 9457 problem details object.  It is the error type
+// returned by the decoding and encoding helpers in this package, so that
+// handlers can relay it to the client using WriteProblem.
+type Problem struct {
+	Type   string `json:"type,omitempty"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func newProblem(status int, format string, a ...any) *Problem {
+	return &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: fmt.Sprintf(format, a...),
+	}
+}
+
+func (o *Problem) Error() string {
+	if o.Detail == "" {
+		return o.Title
+	}
+	return o.Title + ": " + o.Detail
+}
+
+// WriteProblem sends p as an application/problem+json response
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, p.Error(), p.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", MediaTypeProblemJSON)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(b)
+}
@@ -4,7 +4,10 @@
 package cmw
 
 import (
+	"encoding/json"
+	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -21,11 +24,16 @@ const (
 
 const IndicatorNone = 0
 
+// IndicatorMask has all the bits defined in the CMW indicators registry set.
+// Indicators with any other bit set are rejected when decoding.
+const IndicatorMask = ReferenceValues | Endorsements | Evidence | AttestationResults | TrustAnchors
+
 var indMap = map[Indicator]string{
 	ReferenceValues:    "reference values",
 	Endorsements:       "endorsements",
 	Evidence:           "evidence",
 	AttestationResults: "attestation results",
+	TrustAnchors:       "trust anchors",
 }
 
 func (o *Indicator) Set(v Indicator)     { *o |= v }
@@ -33,7 +41,10 @@ func (o *Indicator) Clear(v Indicator)   { *o &= ^v }
 func (o *Indicator) Toggle(v Indicator)  { *o ^= v }
 func (o Indicator) Has(v Indicator) bool { return o&v != 0 }
 func (o Indicator) Empty() bool          { return o == IndicatorNone }
-func (o Indicator) String() string {
+func (o Indicator) String() string       { return strings.Join(o.Names(), ", ") }
+
+// Names returns the (sorted) names of the defined bits that are set
+func (o Indicator) Names() []string {
 	var a []string
 
 	for k, v := range indMap {
@@ -44,5 +55,127 @@ func (o Indicator) String() string {
 
 	sort.Strings(a)
 
-	return strings.Join(a, ", ")
+	return a
+}
+
+// Validate checks that only the bits defined in the CMW indicators registry
+// are set
+func (o Indicator) Validate() error {
+	if u := o &^ IndicatorMask; u != 0 {
+		return fmt.Errorf("indicator %d has undefined bits set (0x%x), max registered value is %d", uint(o), uint(u), IndicatorMask)
+	}
+	return nil
+}
+
+// ParseIndicator parses a comma-separated list of indicator names, e.g.,
+// "evidence,endorsements".  Names are case-insensitive, and spaces, hyphens
+// and underscores in them are interchangeable, so "reference values",
+// "Reference-Values" and "reference_values" are all accepted.  Numeric values
+// are also accepted and or-ed in.  An empty string, or "none", is parsed as
+// IndicatorNone.
+func ParseIndicator(s string) (Indicator, error) {
+	var ind Indicator
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.EqualFold(name, "none") {
+			continue
+		}
+
+		if n, err := strconv.ParseUint(name, 10, 8); err == nil {
+			ind.Set(Indicator(n))
+			continue
+		}
+
+		v, ok := indicatorByName(name)
+		if !ok {
+			return IndicatorNone, fmt.Errorf("unknown indicator %q", name)
+		}
+
+		ind.Set(v)
+	}
+
+	if err := ind.Validate(); err != nil {
+		return IndicatorNone, err
+	}
+
+	return ind, nil
+}
+
+func indicatorByName(name string) (Indicator, bool) {
+	norm := strings.NewReplacer("-", " ", "_", " ").Replace(strings.ToLower(name))
+
+	for k, v := range indMap {
+		if v == norm {
+			return k, true
+		}
+	}
+
+	return IndicatorNone, false
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the indicator as
+// its String form.  Use ParseIndicator or UnmarshalText to decode it.
+func (o Indicator) MarshalText() ([]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler (see ParseIndicator)
+func (o *Indicator) UnmarshalText(b []byte) error {
+	v, err := ParseIndicator(string(b))
+	if err != nil {
+		return err
+	}
+	*o = v
+	return nil
+}
+
+// MarshalJSON encodes the indicator as a JSON number, as required by the CMW
+// record format (rather than as text, which TextMarshaler would imply)
+func (o Indicator) MarshalJSON() ([]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(uint(o))
+}
+
+// UnmarshalJSON decodes a JSON number, rejecting undefined bits
+func (o *Indicator) UnmarshalJSON(b []byte) error {
+	var v uint
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("cannot decode indicator: %w", err)
+	}
+
+	return o.setValidated(v)
+}
+
+// MarshalCBOR encodes the indicator as a CBOR unsigned integer
+func (o Indicator) MarshalCBOR() ([]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return em.Marshal(uint(o))
+}
+
+// UnmarshalCBOR decodes a CBOR unsigned integer, rejecting undefined bits
+func (o *Indicator) UnmarshalCBOR(b []byte) error {
+	var v uint
+
+	if err := dm.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("cannot decode indicator: %w", err)
+	}
+
+	return o.setValidated(v)
+}
+
+func (o *Indicator) setValidated(v uint) error {
+	if err := Indicator(v).Validate(); err != nil {
+		return err
+	}
+	*o = Indicator(v)
+	return nil
 }
@@ -4,9 +4,11 @@
 package cmw
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Indicator_misc(t *testing.T) {
@@ -45,3 +47,94 @@ func Test_Indicator_misc(t *testing.T) {
 	assert.True(t, i.Has(Evidence))
 	assert.True(t, i.Has(TrustAnchors))
 }
+
+func Test_Indicator_String(t *testing.T) {
+	assert.Equal(t, "trust anchors", Indicator(TrustAnchors).String())
+	assert.Equal(t, "attestation results, endorsements, evidence, reference values, trust anchors",
+		Indicator(IndicatorMask).String())
+	assert.Equal(t, "", Indicator(IndicatorNone).String())
+	assert.Equal(t, []string{"endorsements", "evidence"}, Indicator(Evidence|Endorsements).Names())
+}
+
+func Test_ParseIndicator(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Indicator
+	}{
+		{"evidence,endorsements", Evidence | Endorsements},
+		{" Reference-Values , trust_anchors", ReferenceValues | TrustAnchors},
+		{"attestation results", AttestationResults},
+		{"evidence, 8", Evidence | AttestationResults},
+		{"", IndicatorNone},
+		{"none", IndicatorNone},
+		{Indicator(IndicatorMask).String(), IndicatorMask},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			actual, err := ParseIndicator(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func Test_ParseIndicator_ko(t *testing.T) {
+	_, err := ParseIndicator("evidence,bogus")
+	assert.EqualError(t, err, `unknown indicator "bogus"`)
+
+	_, err = ParseIndicator("64")
+	assert.EqualError(t, err, "indicator 64 has undefined bits set (0x40), max registered value is 31")
+}
+
+func Test_Indicator_text(t *testing.T) {
+	type wrapper struct {
+		Ind Indicator `json:"ind"`
+		M   map[Indicator]int
+	}
+
+	in := wrapper{
+		Ind: Evidence | TrustAnchors,
+		M:   map[Indicator]int{Endorsements | ReferenceValues: 1},
+	}
+
+	// map keys use the text encoding, values use the numeric one
+	b, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ind": 20, "M": {"endorsements, reference values": 1}}`, string(b))
+
+	var out wrapper
+	require.NoError(t, json.Unmarshal(b, &out))
+	assert.Equal(t, in, out)
+
+	var i Indicator
+	assert.EqualError(t, i.UnmarshalText([]byte("bogus")), `unknown indicator "bogus"`)
+
+	_, err = Indicator(128).MarshalText()
+	assert.EqualError(t, err, "indicator 128 has undefined bits set (0x80), max registered value is 31")
+}
+
+func Test_Indicator_range(t *testing.T) {
+	var i Indicator
+
+	assert.NoError(t, i.UnmarshalCBOR([]byte{0x18, 0x1f}))
+	assert.Equal(t, Indicator(IndicatorMask), i)
+
+	assert.EqualError(t, i.UnmarshalCBOR([]byte{0x18, 0x20}),
+		"indicator 32 has undefined bits set (0x20), max registered value is 31")
+
+	assert.EqualError(t, i.UnmarshalJSON([]byte("1024")),
+		"indicator 1024 has undefined bits set (0x400), max registered value is 31")
+
+	assert.ErrorContains(t, i.UnmarshalCBOR([]byte{0x20}), "cannot decode indicator")
+
+	_, err := Indicator(32).MarshalCBOR()
+	assert.Error(t, err)
+
+	_, err = Indicator(32).MarshalJSON()
+	assert.Error(t, err)
+
+	b, err := Indicator(Evidence).MarshalCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x04}, b)
+}
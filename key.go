@@ -0,0 +1,182 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	cose "github.com/veraison/go-cose"
+)
+
+// ParseSigningKey parses a private key in JWK or PEM (PKCS#8, SEC 1 or PKCS#1)
+// format.  EC, RSA and Ed25519 keys are supported.
+func ParseSigningKey(b []byte) (crypto.Signer, error) {
+	raw, err := parseKey(b)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := raw.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("want private key, got %T", raw)
+	}
+
+	return signer, nil
+}
+
+// ParseVerificationKey parses a public key in JWK or PEM (SubjectPublicKeyInfo
+// or X.509 certificate) format.  Private keys are also accepted, in which case
+// the associated public key is returned.
+func ParseVerificationKey(b []byte) (crypto.PublicKey, error) {
+	raw, err := parseKey(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if signer, ok := raw.(crypto.Signer); ok {
+		return signer.Public(), nil
+	}
+
+	return raw, nil
+}
+
+// DefaultCOSEAlgorithm returns the COSE signature algorithm conventionally
+// associated with the supplied key: ES256, ES384 or ES512 for EC keys
+// (depending on the curve), EdDSA for Ed25519 keys and PS256 for RSA keys.
+func DefaultCOSEAlgorithm(key crypto.PublicKey) (cose.Algorithm, error) {
+	switch t := publicKey(key).(type) {
+	case *ecdsa.PublicKey:
+		switch t.Curve {
+		case elliptic.P256():
+			return cose.AlgorithmES256, nil
+		case elliptic.P384():
+			return cose.AlgorithmES384, nil
+		case elliptic.P521():
+			return cose.AlgorithmES512, nil
+		}
+		return 0, fmt.Errorf("unsupported EC curve: %s", t.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return cose.AlgorithmEdDSA, nil
+	case *rsa.PublicKey:
+		return cose.AlgorithmPS256, nil
+	default:
+		return 0, fmt.Errorf("unsupported key type: %T", t)
+	}
+}
+
+// DefaultJWSAlgorithm is the JWS counterpart of DefaultCOSEAlgorithm
+func DefaultJWSAlgorithm(key crypto.PublicKey) (jwa.SignatureAlgorithm, error) {
+	alg, err := DefaultCOSEAlgorithm(key)
+	if err != nil {
+		return "", err
+	}
+
+	switch alg {
+	case cose.AlgorithmES256:
+		return jwa.ES256, nil
+	case cose.AlgorithmES384:
+		return jwa.ES384, nil
+	case cose.AlgorithmES512:
+		return jwa.ES512, nil
+	case cose.AlgorithmEdDSA:
+		return jwa.EdDSA, nil
+	default:
+		return jwa.PS256, nil
+	}
+}
+
+func publicKey(key crypto.PublicKey) crypto.PublicKey {
+	if signer, ok := key.(crypto.Signer); ok {
+		return signer.Public()
+	}
+	return key
+}
+
+func parseKey(b []byte) (any, error) {
+	b = bytes.TrimSpace(b)
+
+	if len(b) == 0 {
+		return nil, errors.New("empty key")
+	}
+
+	if startJSONCollection(b[0]) {
+		return parseJWK(b)
+	}
+
+	if bytes.HasPrefix(b, pemPrefix) {
+		return parsePEMKey(b)
+	}
+
+	return nil, errors.New("unknown key format: want JWK or PEM")
+}
+
+func parseJWK(b []byte) (any, error) {
+	k, err := jwk.ParseKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing JWK: %w", err)
+	}
+
+	var raw any
+	if err := k.Raw(&raw); err != nil {
+		return nil, fmt.Errorf("extracting key material from JWK: %w", err)
+	}
+
+	// normalise Ed25519 keys to their pointer-less form, like crypto/x509
+	// does
+	switch t := raw.(type) {
+	case *ed25519.PrivateKey:
+		raw = *t
+	case *ed25519.PublicKey:
+		raw = *t
+	}
+
+	return raw, nil
+}
+
+func parsePEMKey(b []byte) (any, error) {
+	blk, _ := pem.Decode(b)
+	if blk == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var (
+		raw any
+		err error
+	)
+
+	switch blk.Type {
+	case "PRIVATE KEY":
+		raw, err = x509.ParsePKCS8PrivateKey(blk.Bytes)
+	case "EC PRIVATE KEY":
+		raw, err = x509.ParseECPrivateKey(blk.Bytes)
+	case "RSA PRIVATE KEY":
+		raw, err = x509.ParsePKCS1PrivateKey(blk.Bytes)
+	case "PUBLIC KEY":
+		raw, err = x509.ParsePKIXPublicKey(blk.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(blk.Bytes); err == nil {
+			raw = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", blk.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", blk.Type, err)
+	}
+
+	return raw, nil
+}
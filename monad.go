@@ -1,6 +1,7 @@
 package cmw
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -13,13 +14,33 @@ type monad struct {
 	ind Indicator
 
 	format Format
+
+	// set (instead of val) for values backed by a reader
+	src *valueSource
 }
 
 func (o monad) getType() string         { return o.typ.String() }
 func (o monad) getValue() []byte        { return o.val }
 func (o monad) getIndicator() Indicator { return o.ind }
 
-func (o monad) MarshalJSON() ([]byte, error) { return recordEncode(json.Marshal, &o) }
+func (o monad) validate() error {
+	if !o.typ.IsSet() || !o.hasValue() {
+		return fmt.Errorf("type and value MUST be set in CMW")
+	}
+	return nil
+}
+
+func (o monad) MarshalJSON() ([]byte, error) {
+	if o.src != nil {
+		var b bytes.Buffer
+		if err := o.writeJSON(&b); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	return recordEncode(json.Marshal, &o)
+}
 
 func (o *monad) UnmarshalJSON(b []byte) error {
 	if err := recordDecode[json.RawMessage](json.Unmarshal, b, o); err != nil {
@@ -32,9 +53,17 @@ func (o *monad) UnmarshalJSON(b []byte) error {
 }
 
 func (o monad) MarshalCBOR() ([]byte, error) {
+	if o.src != nil {
+		var b bytes.Buffer
+		if err := o.writeCBOR(&b); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
 	s := o.format
 	switch s {
-	case FormatCBORRecord, FormatUnknown: // XXX if it is not explicitly set, use the record format
+	case FormatCBORRecord, FormatJSONRecord, FormatUnknown: // XXX if it is not explicitly set (or JSON), use the record format
 		return recordEncode(em.Marshal, &o)
 	case FormatCBORTag:
 		return o.encodeCBORTag()
@@ -92,6 +121,11 @@ func (o *monad) decodeCBORTag(b []byte) error {
 		err error
 	)
 
+	// cbor.RawTag slices the tag number without checking the input length
+	if err = checkCBORTagHead(b); err != nil {
+		return fmt.Errorf("unmarshal CMW CBOR Tag: %w", err)
+	}
+
 	if err = v.UnmarshalCBOR(b); err != nil {
 		return fmt.Errorf("unmarshal CMW CBOR Tag: %w", err)
 	}
@@ -74,10 +74,10 @@ func Test_Deserialize_monad_ok(t *testing.T) {
 				0xda, 0x63, 0x74, 0x76, 0x32, 0x44, 0xde, 0xad, 0xbe, 0xef,
 			},
 			monad{
-				Type{uint64(1668576818)},
-				[]byte{0xde, 0xad, 0xbe, 0xef},
-				IndicatorNone,
-				FormatCBORTag,
+				typ:    Type{uint64(1668576818)},
+				val:    []byte{0xde, 0xad, 0xbe, 0xef},
+				ind:    IndicatorNone,
+				format: FormatCBORTag,
 			},
 		},
 	}
@@ -315,7 +315,12 @@ func Test_UnmarshalJSON_record_ko(t *testing.T) {
 		{
 			"bad indicator",
 			[]byte(`[10000, "3q2-7w", "Evidence"]`),
-			`unmarshaling indicator: json: cannot unmarshal string into Go value of type cmw.Indicator`,
+			`unmarshaling indicator: cannot decode indicator: json: cannot unmarshal string into Go value of type uint`,
+		},
+		{
+			"undefined indicator bits",
+			[]byte(`[10000, "3q2-7w", 32]`),
+			`unmarshaling indicator: indicator 32 has undefined bits set (0x20), max registered value is 31`,
 		},
 	}
 
@@ -464,3 +469,21 @@ func Test_NewMonad_fail_bad_type(t *testing.T) {
 	_, err := NewMonad(0xffffffff, []byte{0x00})
 	assert.EqualError(t, err, `unsupported type int for CMW type`)
 }
+
+func Test_NewMonad_fail_bad_indicator(t *testing.T) {
+	_, err := NewMonad("application/vnd.a", []byte{0x00}, Evidence, Indicator(64))
+	assert.EqualError(t, err, "indicator 68 has undefined bits set (0x40), max registered value is 31")
+}
+
+func Test_MarshalCBOR_from_JSON_record_ok(t *testing.T) {
+	var cmw CMW
+	err := cmw.Deserialize([]byte(`["application/vnd.example", "3q2-7w", 4]`))
+	require.NoError(t, err)
+
+	actual, err := cmw.MarshalCBOR()
+	require.NoError(t, err)
+
+	// ["application/vnd.example", h'deadbeef', 4]
+	expected := mustHexDecode(`83776170706c69636174696f6e2f766e642e6578616d706c6544deadbeef04`)
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,262 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sync"
+)
+
+// Profile describes the items that a collection with a given __cmwc_t is
+// expected to carry.  Once registered (see RegisterProfile), the profile is
+// enforced when collections of that type are decoded from CBOR or JSON, and
+// when they are validated (ValidateCollection, Marshal, Builder.Done,
+// CollectionBuilder.Build).
+//
+// Profiles are not enforced on the payload of a selectively disclosed
+// collection (see CMW.VerifySDCBOR), since redacted items may be required.
+type Profile struct {
+	// Items maps collection keys to the rules for the associated items.
+	// Integer keys of any Go integer type are accepted.
+	Items map[any]ItemRule
+	// AllowUnknown permits items whose key is not listed in Items
+	AllowUnknown bool
+}
+
+// ItemRule constrains a collection item.  The zero value allows any optional
+// item.
+type ItemRule struct {
+	// Required makes the item mandatory
+	Required bool
+	// Kind, if not KindUnknown, is the kind the item must have
+	Kind Kind
+	// MediaTypes, if not empty, lists the media types allowed for a monad,
+	// ignoring parameters, as path.Match patterns (e.g., "application/eat+*").
+	// Content-Format and CBOR tag numbers are resolved through the registry.
+	MediaTypes []string
+	// Indicators, if not zero, is the set of indicators a monad may have
+	Indicators Indicator
+	// CollectionType, if set, is the __cmwc_t a nested collection must have.
+	// The nested collection is in turn checked against the profile
+	// registered for that type, if any.
+	CollectionType string
+}
+
+var profiles = struct {
+	sync.RWMutex
+	m map[string]Profile
+}{m: make(map[string]Profile)}
+
+// RegisterProfile registers profile p for the collection type ctyp.  It fails
+// if ctyp is not a valid collection type, if p is malformed, or if a profile
+// is already registered for ctyp.
+func RegisterProfile(ctyp string, p Profile) error {
+	if err := validateCollectionType(ctyp); err != nil {
+		return err
+	}
+
+	np, err := p.normalize()
+	if err != nil {
+		return fmt.Errorf("profile for %q: %w", ctyp, err)
+	}
+
+	profiles.Lock()
+	defer profiles.Unlock()
+
+	if _, found := profiles.m[ctyp]; found {
+		return fmt.Errorf("profile already registered for %q", ctyp)
+	}
+
+	profiles.m[ctyp] = np
+
+	return nil
+}
+
+// UnregisterProfile removes the profile registered for ctyp, if any
+func UnregisterProfile(ctyp string) {
+	profiles.Lock()
+	defer profiles.Unlock()
+
+	delete(profiles.m, ctyp)
+}
+
+// LookupProfile returns a copy of the profile registered for ctyp
+func LookupProfile(ctyp string) (Profile, bool) {
+	profiles.RLock()
+	defer profiles.RUnlock()
+
+	p, found := profiles.m[ctyp]
+	if !found {
+		return Profile{}, false
+	}
+
+	return p.clone(), true
+}
+
+// normalize checks the rules and returns a copy of the profile with integer
+// keys converted to the types used by decoded collections
+func (o Profile) normalize() (Profile, error) {
+	np := Profile{
+		Items:        make(map[any]ItemRule, len(o.Items)),
+		AllowUnknown: o.AllowUnknown,
+	}
+
+	for k, r := range o.Items {
+		nk, err := builderKey(k)
+		if err != nil {
+			return Profile{}, err
+		}
+
+		if err := r.validate(); err != nil {
+			return Profile{}, fmt.Errorf("rule for item %v: %w", nk, err)
+		}
+
+		if _, found := np.Items[nk]; found {
+			return Profile{}, fmt.Errorf("duplicate key %v", nk)
+		}
+
+		r.MediaTypes = slices.Clone(r.MediaTypes)
+		np.Items[nk] = r
+	}
+
+	return np, nil
+}
+
+func (o Profile) clone() Profile {
+	c := Profile{
+		Items:        make(map[any]ItemRule, len(o.Items)),
+		AllowUnknown: o.AllowUnknown,
+	}
+
+	for k, r := range o.Items {
+		r.MediaTypes = slices.Clone(r.MediaTypes)
+		c.Items[k] = r
+	}
+
+	return c
+}
+
+func (o ItemRule) validate() error {
+	switch o.Kind {
+	case KindUnknown, KindMonad, KindCollection:
+	default:
+		return fmt.Errorf("unknown kind %d", o.Kind)
+	}
+
+	monadOnly := len(o.MediaTypes) > 0 || o.Indicators != IndicatorNone
+
+	if monadOnly && o.Kind == KindCollection {
+		return errors.New("media types and indicators apply to monads only")
+	}
+
+	if o.CollectionType != "" {
+		if o.Kind == KindMonad || monadOnly {
+			return errors.New("collection type applies to collections only")
+		}
+		if err := validateCollectionType(o.CollectionType); err != nil {
+			return err
+		}
+	}
+
+	for _, mt := range o.MediaTypes {
+		if _, err := path.Match(mt, ""); err != nil {
+			return fmt.Errorf("bad media type pattern %q: %w", mt, err)
+		}
+	}
+
+	return o.Indicators.Validate()
+}
+
+// checkProfile enforces the profile registered for the collection's type, if
+// any
+func (o collection) checkProfile() error {
+	if o.ctyp == "" {
+		return nil
+	}
+
+	profiles.RLock()
+	p, found := profiles.m[o.ctyp]
+	profiles.RUnlock()
+
+	if !found {
+		return nil
+	}
+
+	if err := p.check(o); err != nil {
+		return fmt.Errorf("collection type %q: %w", o.ctyp, err)
+	}
+
+	return nil
+}
+
+func (o Profile) check(c collection) error {
+	keys := keyIndex(c)
+
+	rules := make(map[any]any, len(o.Items))
+	for k := range o.Items {
+		rules[k] = k
+	}
+
+	for _, k := range mergeKeys(rules, keys) {
+		r, inProfile := o.Items[k]
+		ak, inCollection := keys[k]
+
+		switch {
+		case !inProfile:
+			if !o.AllowUnknown {
+				return fmt.Errorf("unexpected item %v", ak)
+			}
+		case !inCollection:
+			if r.Required {
+				return fmt.Errorf("missing required item %v", k)
+			}
+		default:
+			if err := r.check(c.cmap[ak]); err != nil {
+				return fmt.Errorf("item %v: %w", k, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (o ItemRule) check(item CMW) error {
+	if o.Kind != KindUnknown && item.kind != o.Kind {
+		return fmt.Errorf("want %s, got %q", o.Kind, item.kind)
+	}
+
+	switch item.kind {
+	case KindMonad:
+		if len(o.MediaTypes) > 0 || o.Indicators != IndicatorNone {
+			return o.checkMonad(item.monad)
+		}
+		if o.CollectionType != "" {
+			return fmt.Errorf("want collection, got %q", item.kind)
+		}
+	case KindCollection:
+		if len(o.MediaTypes) > 0 || o.Indicators != IndicatorNone {
+			return fmt.Errorf("want monad, got %q", item.kind)
+		}
+		if o.CollectionType != "" && item.collection.ctyp != o.CollectionType {
+			return fmt.Errorf("want collection type %q, got %q", o.CollectionType, item.collection.ctyp)
+		}
+	}
+
+	return nil
+}
+
+func (o ItemRule) checkMonad(m monad) error {
+	if len(o.MediaTypes) > 0 && !matchMediaType(o.MediaTypes, m.typ) {
+		return fmt.Errorf("media type %q not allowed", m.typ.String())
+	}
+
+	if o.Indicators != IndicatorNone && m.ind&^o.Indicators != 0 {
+		return fmt.Errorf("indicators %q not allowed", m.ind&^o.Indicators)
+	}
+
+	return nil
+}
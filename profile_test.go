@@ -0,0 +1,221 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testProfileType    = "tag:example.com,2025:profiled"
+	testSubProfileType = "tag:example.com,2025:profiled-sub"
+)
+
+func registerTestProfiles(t *testing.T) {
+	t.Helper()
+
+	require.NoError(t, RegisterProfile(testProfileType, Profile{
+		Items: map[any]ItemRule{
+			"ev": {
+				Required:   true,
+				MediaTypes: []string{"application/eat+*"},
+				Indicators: Evidence,
+			},
+			1: {
+				Kind: KindMonad,
+			},
+			"sub": {
+				CollectionType: testSubProfileType,
+			},
+		},
+	}))
+	t.Cleanup(func() { UnregisterProfile(testProfileType) })
+
+	require.NoError(t, RegisterProfile(testSubProfileType, Profile{
+		Items: map[any]ItemRule{
+			"ar": {Required: true, Indicators: AttestationResults},
+		},
+		AllowUnknown: true,
+	}))
+	t.Cleanup(func() { UnregisterProfile(testSubProfileType) })
+}
+
+func Test_Profile_ok(t *testing.T) {
+	registerTestProfiles(t)
+
+	c, err := Build().
+		Type(testProfileType).
+		Monad("ev", "application/eat+cwt; eat_profile=x", []byte{0x01}, Evidence).
+		Tag(1, uint16(30001), []byte{0x02}).
+		Collection("sub", func(b *Builder) {
+			b.Type(testSubProfileType).
+				Monad("ar", "application/eat+jwt", []byte{0x03}, AttestationResults).
+				Monad("extra", "application/vnd.a", []byte{0x04})
+		}).
+		Done()
+	require.NoError(t, err)
+
+	var decoded CMW
+	require.NoError(t, decoded.UnmarshalCBOR(mustMarshalCBOR(t, c)))
+
+	// JSON collections have string keys only
+	j, err := Build().
+		Type(testProfileType).
+		Monad("ev", "application/eat+jwt", []byte{0x01}).
+		JSON()
+	require.NoError(t, err)
+	require.NoError(t, decoded.UnmarshalJSON(j))
+
+	// unregistered collection types are not checked
+	_, err = Build().
+		Type("tag:example.com,2025:unprofiled").
+		Monad("anything", "application/vnd.a", []byte{0x01}).
+		Done()
+	assert.NoError(t, err)
+}
+
+func Test_Profile_enforced(t *testing.T) {
+	registerTestProfiles(t)
+
+	tvs := []struct {
+		name     string
+		b        *Builder
+		expected string
+	}{
+		{
+			"missing required item",
+			Build().Type(testProfileType).Tag(1, uint16(30001), []byte{0x02}),
+			`collection type "tag:example.com,2025:profiled": missing required item ev`,
+		},
+		{
+			"unexpected item",
+			Build().Type(testProfileType).
+				Monad("ev", "application/eat+cwt", []byte{0x01}).
+				Monad("zzz", "application/vnd.a", []byte{0x01}),
+			`collection type "tag:example.com,2025:profiled": unexpected item zzz`,
+		},
+		{
+			"media type not allowed",
+			Build().Type(testProfileType).
+				Monad("ev", "application/vnd.a", []byte{0x01}),
+			`collection type "tag:example.com,2025:profiled": item ev: media type "application/vnd.a" not allowed`,
+		},
+		{
+			"indicators not allowed",
+			Build().Type(testProfileType).
+				Monad("ev", "application/eat+cwt", []byte{0x01}, Evidence, Endorsements),
+			`collection type "tag:example.com,2025:profiled": item ev: indicators "endorsements" not allowed`,
+		},
+		{
+			"wrong kind",
+			Build().Type(testProfileType).
+				Monad("ev", "application/eat+cwt", []byte{0x01}).
+				Collection(1, func(b *Builder) {
+					b.Monad("a", "application/vnd.a", []byte{0x01})
+				}),
+			`collection type "tag:example.com,2025:profiled": item 1: want monad, got "collection"`,
+		},
+		{
+			"wrong nested collection type",
+			Build().Type(testProfileType).
+				Monad("ev", "application/eat+cwt", []byte{0x01}).
+				Collection("sub", func(b *Builder) {
+					b.Monad("ar", "application/vnd.a", []byte{0x01})
+				}),
+			`collection type "tag:example.com,2025:profiled": item sub: want collection type "tag:example.com,2025:profiled-sub", got ""`,
+		},
+		{
+			"nested profile",
+			Build().Type(testProfileType).
+				Monad("ev", "application/eat+cwt", []byte{0x01}).
+				Collection("sub", func(b *Builder) {
+					b.Type(testSubProfileType).
+						Monad("extra", "application/vnd.a", []byte{0x01})
+				}),
+			`invalid collection at key "sub": collection type "tag:example.com,2025:profiled-sub": missing required item ar`,
+		},
+	}
+
+	for _, tv := range tvs {
+		t.Run(tv.name, func(t *testing.T) {
+			_, err := tv.b.Done()
+			assert.ErrorContains(t, err, tv.expected)
+		})
+	}
+}
+
+func Test_Profile_enforced_on_decode(t *testing.T) {
+	c, err := Build().
+		Type(testProfileType).
+		Monad("zzz", "application/vnd.a", []byte{0x01}).
+		Done()
+	require.NoError(t, err)
+
+	b := mustMarshalCBOR(t, c)
+
+	j, err := c.MarshalJSON()
+	require.NoError(t, err)
+
+	registerTestProfiles(t)
+
+	var decoded CMW
+	assert.EqualError(t, decoded.UnmarshalCBOR(b),
+		`checking CBOR collection profile: collection type "tag:example.com,2025:profiled": missing required item ev`)
+	assert.EqualError(t, decoded.UnmarshalJSON(j),
+		`checking JSON collection profile: collection type "tag:example.com,2025:profiled": missing required item ev`)
+}
+
+func Test_RegisterProfile_ko(t *testing.T) {
+	assert.EqualError(t, RegisterProfile("not a URI", Profile{}),
+		`invalid collection type: "not a URI".  URI is not absolute`)
+
+	tvs := []struct {
+		rule     ItemRule
+		expected string
+	}{
+		{ItemRule{Kind: Kind(7)}, "unknown kind 7"},
+		{ItemRule{Kind: KindCollection, Indicators: Evidence}, "media types and indicators apply to monads only"},
+		{ItemRule{Kind: KindMonad, CollectionType: testSubProfileType}, "collection type applies to collections only"},
+		{ItemRule{MediaTypes: []string{"application/["}}, `bad media type pattern "application/[": syntax error in pattern`},
+		{ItemRule{Indicators: Indicator(64)}, "indicator 64 has undefined bits set (0x40), max registered value is 31"},
+	}
+
+	for _, tv := range tvs {
+		err := RegisterProfile(testProfileType, Profile{Items: map[any]ItemRule{"a": tv.rule}})
+		assert.EqualError(t, err, `profile for "tag:example.com,2025:profiled": rule for item a: `+tv.expected)
+	}
+
+	err := RegisterProfile(testProfileType, Profile{Items: map[any]ItemRule{CmwCType: {}}})
+	assert.EqualError(t, err, `profile for "tag:example.com,2025:profiled": invalid key: bad collection key: __cmwc_t is reserved`)
+
+	err = RegisterProfile(testProfileType, Profile{Items: map[any]ItemRule{1: {}, uint8(1): {}}})
+	assert.EqualError(t, err, `profile for "tag:example.com,2025:profiled": duplicate key 1`)
+
+	registerTestProfiles(t)
+
+	err = RegisterProfile(testProfileType, Profile{})
+	assert.EqualError(t, err, `profile already registered for "tag:example.com,2025:profiled"`)
+}
+
+func Test_LookupProfile(t *testing.T) {
+	_, found := LookupProfile(testProfileType)
+	assert.False(t, found)
+
+	registerTestProfiles(t)
+
+	p, found := LookupProfile(testProfileType)
+	require.True(t, found)
+
+	// integer keys are normalized
+	_, found = p.Items[uint64(1)]
+	assert.True(t, found)
+
+	// the returned profile is a copy
+	p.Items["ev"].MediaTypes[0] = "application/vnd.a"
+	p2, _ := LookupProfile(testProfileType)
+	assert.Equal(t, []string{"application/eat+*"}, p2.Items["ev"].MediaTypes)
+}
@@ -0,0 +1,414 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/fxamacker/cbor/v2"
+	cose "github.com/veraison/go-cose"
+)
+
+// Selective disclosure of collection items, in the spirit of SD-CWT and
+// SD-JWT.
+//
+// The attester uses RedactItems to replace some of the items of a collection
+// with salted digests, and signs the result with SDCollection.SignCBOR.  The
+// redacted items are returned as Disclosures, which travel alongside the
+// signed CMW in the "sd_claims" unprotected header (see PresentSDCBOR).  The
+// holder chooses which of the disclosures to pass on, and the relying party
+// uses VerifySDCBOR to check the signature and the disclosures, and to obtain
+// a collection made of the non-redacted items plus the disclosed ones.
+//
+// In the signed payload, the digests are carried in a sorted array under the
+// simple(59) map key, as in SD-CWT.  Since the SD-CWT header parameters have
+// not been assigned integer labels yet, the text labels "sd_alg" and
+// "sd_claims" are used instead.
+const (
+	SDAlgHeaderLabel    = "sd_alg"
+	SDClaimsHeaderLabel = "sd_claims"
+)
+
+// SDContentType is the content type of the signed payload.  Because of the
+// simple(59) key, the payload is not a valid CMW collection, so it must not be
+// labelled application/cmw+cbor.
+const SDContentType = "application/sd-cmw+cbor"
+
+// sdDigestsKey is the collection map key under which the digests of the
+// redacted items are stored
+const sdDigestsKey = cbor.SimpleValue(59)
+
+const sdSaltLen = 16
+
+// COSE algorithm identifiers (RFC 9054) of the supported digest algorithms
+var sdAlgs = map[crypto.Hash]int64{
+	crypto.SHA256: -16,
+	crypto.SHA384: -43,
+	crypto.SHA512: -44,
+}
+
+func sdAlgFromCOSE(v any) (crypto.Hash, error) {
+	id, ok := v.(int64)
+	if ok {
+		for h, a := range sdAlgs {
+			if a == id {
+				return h, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unsupported %s: %v", SDAlgHeaderLabel, v)
+}
+
+// Disclosure reveals a redacted collection item
+type Disclosure struct {
+	Salt []byte
+	Key  any
+	Item CMW
+
+	// the encoded disclosure, over which the digest is computed
+	raw []byte
+}
+
+// MarshalCBOR encodes the disclosure as [salt, key, item]
+func (o Disclosure) MarshalCBOR() ([]byte, error) {
+	if o.raw != nil {
+		return o.raw, nil
+	}
+
+	item, err := o.Item.MarshalCBOR()
+	if err != nil {
+		return nil, fmt.Errorf("marshaling disclosed item: %w", err)
+	}
+
+	return em.Marshal([]any{o.Salt, o.Key, cbor.RawMessage(item)})
+}
+
+// UnmarshalCBOR decodes a disclosure encoded as [salt, key, item]
+func (o *Disclosure) UnmarshalCBOR(b []byte) error {
+	var tmp struct {
+		_    struct{} `cbor:",toarray"`
+		Salt []byte
+		Key  any
+		Item cbor.RawMessage
+	}
+
+	if err := dm.Unmarshal(b, &tmp); err != nil {
+		return fmt.Errorf("unmarshaling disclosure: %w", err)
+	}
+
+	if len(tmp.Salt) != sdSaltLen {
+		return fmt.Errorf("disclosure salt: want %d bytes, got %d", sdSaltLen, len(tmp.Salt))
+	}
+
+	if err := validateCollectionKey(tmp.Key); err != nil {
+		return fmt.Errorf("disclosure key: %w", err)
+	}
+
+	var item CMW
+	if err := item.UnmarshalCBOR(tmp.Item); err != nil {
+		return fmt.Errorf("unmarshaling disclosed item: %w", err)
+	}
+
+	*o = Disclosure{
+		Salt: tmp.Salt,
+		Key:  tmp.Key,
+		Item: item,
+		raw:  append([]byte(nil), b...),
+	}
+
+	return nil
+}
+
+// Digest returns the digest of the encoded disclosure computed using h
+func (o Disclosure) Digest(h crypto.Hash) ([]byte, error) {
+	hh, err := newHash(h)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := o.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+
+	hh.Write(b)
+
+	return hh.Sum(nil), nil
+}
+
+// SDCollection is a collection CMW in which some of the items have been
+// replaced by the digests of their Disclosures
+type SDCollection struct {
+	alg     crypto.Hash
+	visible collection
+	digests [][]byte
+}
+
+// RedactItems replaces the collection items at keys with salted digests
+// computed using h, and returns the resulting SDCollection together with the
+// Disclosures of the redacted items.  The original CMW is not modified.
+func (o CMW) RedactItems(h crypto.Hash, keys ...any) (*SDCollection, []Disclosure, error) {
+	if o.kind != KindCollection {
+		return nil, nil, fmt.Errorf("want collection, got %q", o.kind)
+	}
+
+	if _, ok := sdAlgs[h]; !ok || !h.Available() {
+		return nil, nil, fmt.Errorf("unsupported %s: %v", SDAlgHeaderLabel, h)
+	}
+
+	sd := SDCollection{
+		alg: h,
+		visible: collection{
+			cmap:   make(map[any]CMW, len(o.cmap)),
+			ctyp:   o.ctyp,
+			format: FormatCBORCollection,
+		},
+	}
+
+	for k, v := range o.cmap {
+		sd.visible.cmap[k] = v
+	}
+
+	disclosures := make([]Disclosure, 0, len(keys))
+
+	for _, k := range keys {
+		item, found := sd.visible.cmap[k]
+		if !found {
+			return nil, nil, fmt.Errorf("item not found for key %q", k)
+		}
+
+		salt := make([]byte, sdSaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, fmt.Errorf("generating salt: %w", err)
+		}
+
+		d := Disclosure{Salt: salt, Key: k, Item: item}
+
+		raw, err := d.MarshalCBOR()
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling disclosure for key %v: %w", k, err)
+		}
+		d.raw = raw
+
+		digest, _ := d.Digest(h)
+
+		sd.digests = append(sd.digests, digest)
+		disclosures = append(disclosures, d)
+
+		delete(sd.visible.cmap, k)
+	}
+
+	// sort the digests so that their order does not leak the redacted keys
+	sort.Slice(sd.digests, func(i, j int) bool { return bytes.Compare(sd.digests[i], sd.digests[j]) < 0 })
+
+	return &sd, disclosures, nil
+}
+
+// MarshalCBOR serializes the SDCollection to CBOR
+func (o SDCollection) MarshalCBOR() ([]byte, error) {
+	m := make(map[any]cbor.RawMessage)
+
+	if o.visible.ctyp != "" {
+		ct, _ := em.Marshal(o.visible.ctyp)
+		m[CmwCType] = cbor.RawMessage(ct)
+	}
+
+	for i, v := range o.visible.cmap {
+		c, err := v.MarshalCBOR()
+		if err != nil {
+			return nil, fmt.Errorf("marshaling CBOR collection item %v: %w", i, err)
+		}
+		m[i] = c
+	}
+
+	if len(o.digests) > 0 {
+		d, err := em.Marshal(o.digests)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling redacted item digests: %w", err)
+		}
+		m[sdDigestsKey] = d
+	}
+
+	b, err := em.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling CBOR collection: %w", err)
+	}
+
+	return b, nil
+}
+
+// SignCBOR produces a signed-cbor-cmw from the SDCollection by signing it with
+// the supplied cose.Signer.  The digest algorithm is recorded in the sd_alg
+// protected header.
+func (o SDCollection) SignCBOR(signer cose.Signer) ([]byte, error) {
+	msg := cose.NewSignMessage()
+
+	msg.Headers.Protected[cose.HeaderLabelAlgorithm] = signer.Algorithm()
+	msg.Headers.Protected[cose.HeaderLabelContentType] = SDContentType
+	msg.Headers.Protected[SDAlgHeaderLabel] = sdAlgs[o.alg]
+
+	payload, err := o.MarshalCBOR()
+	if err != nil {
+		return nil, err
+	}
+
+	return cose.Sign1(rand.Reader, signer, msg.Headers, payload, nil)
+}
+
+// PresentSDCBOR attaches the supplied disclosures to the signed-cbor-cmw,
+// replacing any that were already there.  Since the disclosures are carried
+// in the unprotected header, the signature is unaffected.
+func PresentSDCBOR(signed []byte, disclosures ...Disclosure) ([]byte, error) {
+	msg, err := decodeSignedCBORWithType(signed, SDContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make([][]byte, 0, len(disclosures))
+
+	for i, d := range disclosures {
+		b, err := d.MarshalCBOR()
+		if err != nil {
+			return nil, fmt.Errorf("disclosure %d: %w", i, err)
+		}
+		claims = append(claims, b)
+	}
+
+	// make sure the unprotected header is re-encoded
+	msg.Headers.RawUnprotected = nil
+
+	if len(claims) == 0 {
+		delete(msg.Headers.Unprotected, SDClaimsHeaderLabel)
+	} else {
+		msg.Headers.Unprotected[SDClaimsHeaderLabel] = claims
+	}
+
+	return msg.MarshalCBOR()
+}
+
+// ExtractDisclosures returns the disclosures carried by the signed-cbor-cmw.
+// They are not verified.
+func ExtractDisclosures(signed []byte) ([]Disclosure, error) {
+	msg, err := decodeSignedCBORWithType(signed, SDContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSDClaims(msg)
+}
+
+func decodeSDClaims(msg *cose.Sign1Message) ([]Disclosure, error) {
+	v, ok := msg.Headers.Unprotected[SDClaimsHeaderLabel]
+	if !ok {
+		return nil, nil
+	}
+
+	claims, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: want array, got %T", SDClaimsHeaderLabel, v)
+	}
+
+	disclosures := make([]Disclosure, 0, len(claims))
+
+	for i, c := range claims {
+		b, ok := c.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("%s: item %d: want bstr, got %T", SDClaimsHeaderLabel, i, c)
+		}
+
+		var d Disclosure
+		if err := d.UnmarshalCBOR(b); err != nil {
+			return nil, fmt.Errorf("%s: item %d: %w", SDClaimsHeaderLabel, i, err)
+		}
+
+		disclosures = append(disclosures, d)
+	}
+
+	return disclosures, nil
+}
+
+// VerifySDCBOR verifies the signed-cbor-cmw produced by SDCollection.SignCBOR
+// (and, possibly, PresentSDCBOR) using the supplied cose.Verifier.  Each
+// disclosure carried in the sd_claims header must match one of the signed
+// digests.  If verification succeeds, the CMW target is populated with the
+// non-redacted items and the disclosed ones; items that have not been
+// disclosed are absent.
+func (o *CMW) VerifySDCBOR(verifier cose.Verifier, signed []byte) error {
+	msg, err := decodeSignedCBORWithType(signed, SDContentType)
+	if err != nil {
+		return err
+	}
+
+	v, ok := msg.Headers.Protected[SDAlgHeaderLabel]
+	if !ok {
+		return fmt.Errorf("missing mandatory %s parameter in signed-cbor-cmw protected headers", SDAlgHeaderLabel)
+	}
+
+	h, err := sdAlgFromCOSE(v)
+	if err != nil {
+		return err
+	}
+
+	if msg.Payload == nil {
+		return errors.New("signed-cbor-cmw has a detached payload")
+	}
+
+	if err := msg.Verify(nil, verifier); err != nil {
+		return fmt.Errorf("signed-cbor-cmw signature verification failed: %w", err)
+	}
+
+	var tmp map[any]cbor.RawMessage
+	if err := dm.Unmarshal(msg.Payload, &tmp); err != nil {
+		return fmt.Errorf("CBOR decoding signed-cbor-cmw payload: %w", err)
+	}
+
+	digests := make(map[string]bool)
+
+	if raw, ok := tmp[sdDigestsKey]; ok {
+		var ds [][]byte
+		if err := dm.Unmarshal(raw, &ds); err != nil {
+			return fmt.Errorf("decoding redacted item digests: %w", err)
+		}
+		for _, d := range ds {
+			digests[string(d)] = true
+		}
+		delete(tmp, sdDigestsKey)
+	}
+
+	var c CMW
+	if err := c.collection.fromCBORMap(tmp); err != nil {
+		return fmt.Errorf("CBOR decoding signed-cbor-cmw payload: %w", err)
+	}
+	c.kind = KindCollection
+
+	disclosures, err := decodeSDClaims(msg)
+	if err != nil {
+		return err
+	}
+
+	for i, d := range disclosures {
+		digest, _ := d.Digest(h)
+
+		if !digests[string(digest)] {
+			return fmt.Errorf("disclosure %d: digest not found in signed-cbor-cmw", i)
+		}
+		// each digest can be disclosed only once
+		delete(digests, string(digest))
+
+		if _, found := c.cmap[d.Key]; found {
+			return fmt.Errorf("disclosure %d: duplicate key %v", i, d.Key)
+		}
+
+		c.cmap[d.Key] = d.Item
+	}
+
+	*o = c
+
+	return nil
+}
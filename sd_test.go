@@ -0,0 +1,199 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cose "github.com/veraison/go-cose"
+)
+
+func Test_SelectiveDisclosure_roundtrip(t *testing.T) {
+	signer, verifier, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+
+	in := makeCMWCollection()
+
+	sd, disclosures, err := in.RedactItems(crypto.SHA256, "murmurless", "photoelectrograph")
+	require.NoError(t, err)
+	require.Len(t, disclosures, 2)
+
+	// the original collection is untouched
+	_, err = in.GetCollectionItem("murmurless")
+	assert.NoError(t, err)
+
+	signed, err := sd.SignCBOR(signer)
+	require.NoError(t, err)
+
+	// the payload is not a plain CMW collection
+	var plain CMW
+	assert.EqualError(t, plain.VerifyCBOR(verifier, signed),
+		"unexpected content type in signed-cbor-cmw: application/sd-cmw+cbor")
+
+	tests := []struct {
+		name     string
+		disclose []Disclosure
+		present  []string
+		absent   []string
+	}{
+		{"none", nil, []string{"bretwaldadom"}, []string{"murmurless", "photoelectrograph"}},
+		{"some", disclosures[1:], []string{"bretwaldadom", "photoelectrograph"}, []string{"murmurless"}},
+		{"all", disclosures, []string{"bretwaldadom", "murmurless", "photoelectrograph"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			presented, err := PresentSDCBOR(signed, tt.disclose...)
+			require.NoError(t, err)
+
+			var out CMW
+			require.NoError(t, out.VerifySDCBOR(verifier, presented))
+
+			ctyp, err := out.GetCollectionType()
+			require.NoError(t, err)
+			assert.Equal(t, "tag:ietf.org,2024:X", ctyp)
+
+			for _, k := range tt.present {
+				actual, err := out.GetCollectionItem(k)
+				require.NoError(t, err, k)
+
+				expected, _ := in.GetCollectionItem(k)
+				assert.Equal(t, mustMarshalCBOR(t, expected), mustMarshalCBOR(t, actual), k)
+			}
+
+			for _, k := range tt.absent {
+				_, err := out.GetCollectionItem(k)
+				assert.Error(t, err, k)
+			}
+		})
+	}
+}
+
+func Test_SelectiveDisclosure_holder_reselects(t *testing.T) {
+	signer, verifier, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+
+	sd, disclosures, err := makeCMWCollection().RedactItems(crypto.SHA384, "murmurless", "bretwaldadom")
+	require.NoError(t, err)
+
+	signed, err := sd.SignCBOR(signer)
+	require.NoError(t, err)
+
+	// the attester hands everything over to the holder...
+	toHolder, err := PresentSDCBOR(signed, disclosures...)
+	require.NoError(t, err)
+
+	// ...who passes on the "bretwaldadom" item only
+	received, err := ExtractDisclosures(toHolder)
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+
+	var chosen []Disclosure
+	for _, d := range received {
+		if d.Key == "bretwaldadom" {
+			chosen = append(chosen, d)
+		}
+	}
+
+	presented, err := PresentSDCBOR(toHolder, chosen...)
+	require.NoError(t, err)
+
+	var out CMW
+	require.NoError(t, out.VerifySDCBOR(verifier, presented))
+
+	meta, err := out.GetCollectionMeta()
+	require.NoError(t, err)
+	assert.Equal(t, []Meta{{"bretwaldadom", KindMonad}, {"photoelectrograph", KindMonad}}, meta)
+}
+
+func Test_SelectiveDisclosure_ko(t *testing.T) {
+	signer, verifier, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+
+	in := makeCMWCollection()
+
+	_, _, err = in.RedactItems(crypto.SHA256, "missing")
+	assert.EqualError(t, err, `item not found for key "missing"`)
+
+	_, _, err = in.RedactItems(crypto.MD5, "murmurless")
+	assert.EqualError(t, err, "unsupported sd_alg: MD5")
+
+	m, _ := in.GetCollectionItem("bretwaldadom")
+	_, _, err = m.RedactItems(crypto.SHA256)
+	assert.EqualError(t, err, `want collection, got "monad"`)
+
+	sd, disclosures, err := in.RedactItems(crypto.SHA256, "murmurless")
+	require.NoError(t, err)
+
+	signed, err := sd.SignCBOR(signer)
+	require.NoError(t, err)
+
+	// a disclosure from another signed CMW
+	_, foreign, err := in.RedactItems(crypto.SHA256, "photoelectrograph")
+	require.NoError(t, err)
+
+	// a disclosure whose item has been tampered with
+	tampered := Disclosure{Salt: disclosures[0].Salt, Key: disclosures[0].Key, Item: *m}
+
+	plain, err := in.SignCBOR(signer)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		signed      []byte
+		disclosures []Disclosure
+		err         string
+	}{
+		{"foreign", signed, foreign, "disclosure 0: digest not found in signed-cbor-cmw"},
+		{"tampered", signed, []Disclosure{tampered}, "disclosure 0: digest not found in signed-cbor-cmw"},
+		{"replayed", signed, append(disclosures, disclosures...), "disclosure 1: digest not found in signed-cbor-cmw"},
+		{"not SD", plain, nil, "unexpected content type in signed-cbor-cmw: application/cmw+cbor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// without disclosures, the signed message is verified as is
+			presented := tt.signed
+			if tt.disclosures != nil {
+				var err error
+				presented, err = PresentSDCBOR(tt.signed, tt.disclosures...)
+				require.NoError(t, err)
+			}
+
+			var out CMW
+			assert.EqualError(t, out.VerifySDCBOR(verifier, presented), tt.err)
+		})
+	}
+
+	// a plain signed-cbor-cmw cannot be presented either
+	_, err = PresentSDCBOR(plain)
+	assert.EqualError(t, err, "unexpected content type in signed-cbor-cmw: application/cmw+cbor")
+}
+
+func Test_Disclosure_CBOR_roundtrip(t *testing.T) {
+	_, disclosures, err := makeCMWCollection().RedactItems(crypto.SHA256, "photoelectrograph")
+	require.NoError(t, err)
+
+	b, err := disclosures[0].MarshalCBOR()
+	require.NoError(t, err)
+
+	var d Disclosure
+	require.NoError(t, d.UnmarshalCBOR(b))
+	assert.Equal(t, "photoelectrograph", d.Key)
+	assert.Len(t, d.Salt, 16)
+
+	expected, err := disclosures[0].Digest(crypto.SHA256)
+	require.NoError(t, err)
+
+	actual, err := d.Digest(crypto.SHA256)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	// [h'00', "k", ...]: short salt
+	assert.EqualError(t, d.UnmarshalCBOR([]byte{0x83, 0x41, 0x00, 0x61, 0x6b, 0x80}),
+		"disclosure salt: want 16 bytes, got 1")
+}
@@ -2,6 +2,7 @@ package cmw
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 
 	cose "github.com/veraison/go-cose"
@@ -23,25 +24,47 @@ func (o CMW) SignCBOR(signer cose.Signer) ([]byte, error) {
 	return cose.Sign1(rand.Reader, signer, msg.Headers, payload, nil)
 }
 
+// SignCBORDetached is like SignCBOR, except that the returned COSE_Sign1 has a
+// nil payload.  The CBOR-encoded CMW that has been signed is returned
+// alongside the signature, and must be transported separately.
+func (o CMW) SignCBORDetached(signer cose.Signer) ([]byte, []byte, error) {
+	msg := cose.NewSign1Message()
+
+	msg.Headers.Protected[cose.HeaderLabelAlgorithm] = signer.Algorithm()
+	msg.Headers.Protected[cose.HeaderLabelContentType] = "application/cmw+cbor"
+
+	payload, err := o.MarshalCBOR()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg.Payload = payload
+
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		return nil, nil, err
+	}
+
+	msg.Payload = nil
+
+	sig, err := msg.MarshalCBOR()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sig, payload, nil
+}
+
 // VerifyCBOR verifies the signed-cbor-cmw using the supplied cose.Verifier.  If
 // the signature is succesfully validated and the payload CMW is correctly
 // formatted, the CMW target is populated.
 func (o *CMW) VerifyCBOR(verifier cose.Verifier, cbor []byte) error {
-	var msg cose.Sign1Message
-	if err := msg.UnmarshalCBOR(cbor); err != nil {
-		return fmt.Errorf("CBOR decoding signed-cbor-cmw: %w", err)
-	}
-
-	if v, ok := msg.Headers.Protected[cose.HeaderLabelContentType]; ok {
-		if v != "application/cmw+cbor" {
-			return fmt.Errorf("unexpected content type in signed-cbor-cmw: %v", v)
-		}
-	} else {
-		return fmt.Errorf("missing mandatory cty parameter in signed-cbor-cmw protected headers")
+	msg, err := decodeSignedCBOR(cbor)
+	if err != nil {
+		return err
 	}
 
-	if _, ok := msg.Headers.Protected[cose.HeaderLabelAlgorithm]; !ok {
-		return fmt.Errorf("missing mandatory alg parameter in signed-cbor-cmw protected headers")
+	if msg.Payload == nil {
+		return errors.New("signed-cbor-cmw has a detached payload")
 	}
 
 	if err := msg.Verify(nil, verifier); err != nil {
@@ -54,3 +77,61 @@ func (o *CMW) VerifyCBOR(verifier cose.Verifier, cbor []byte) error {
 
 	return nil
 }
+
+// VerifyCBORDetached verifies the detached-payload signed-cbor-cmw in sig over
+// the supplied serialized CMW using the supplied cose.Verifier.  If the
+// signature is successfully validated and the payload deserializes to a
+// correctly formatted CMW, the CMW target is populated.
+func (o *CMW) VerifyCBORDetached(verifier cose.Verifier, sig []byte, payload []byte) error {
+	msg, err := decodeSignedCBOR(sig)
+	if err != nil {
+		return err
+	}
+
+	if msg.Payload != nil {
+		return errors.New("signed-cbor-cmw has an embedded payload, want detached")
+	}
+
+	if len(payload) == 0 {
+		return errors.New("empty detached payload")
+	}
+
+	msg.Payload = payload
+
+	if err := msg.Verify(nil, verifier); err != nil {
+		return fmt.Errorf("signed-cbor-cmw signature verification failed: %w", err)
+	}
+
+	if err := o.Deserialize(payload); err != nil {
+		return fmt.Errorf("decoding detached signed-cbor-cmw payload: %w", err)
+	}
+
+	return nil
+}
+
+func decodeSignedCBOR(b []byte) (*cose.Sign1Message, error) {
+	return decodeSignedCBORWithType(b, "application/cmw+cbor")
+}
+
+// decodeSignedCBORWithType is like decodeSignedCBOR, but the payload is
+// expected to have the supplied content type
+func decodeSignedCBORWithType(b []byte, cty string) (*cose.Sign1Message, error) {
+	var msg cose.Sign1Message
+	if err := msg.UnmarshalCBOR(b); err != nil {
+		return nil, fmt.Errorf("CBOR decoding signed-cbor-cmw: %w", err)
+	}
+
+	if v, ok := msg.Headers.Protected[cose.HeaderLabelContentType]; ok {
+		if v != cty {
+			return nil, fmt.Errorf("unexpected content type in signed-cbor-cmw: %v", v)
+		}
+	} else {
+		return nil, fmt.Errorf("missing mandatory cty parameter in signed-cbor-cmw protected headers")
+	}
+
+	if _, ok := msg.Headers.Protected[cose.HeaderLabelAlgorithm]; !ok {
+		return nil, fmt.Errorf("missing mandatory alg parameter in signed-cbor-cmw protected headers")
+	}
+
+	return &msg, nil
+}
@@ -68,3 +68,56 @@ func TestCMW_Signed_CBOR_Verify_phdr_failures(t *testing.T) {
 		assert.ErrorContains(t, err, tv.e)
 	}
 }
+
+func TestCMW_Signed_CBOR_Detached_roundtrip_ok(t *testing.T) {
+	in := makeCMWCollection()
+	c0, _ := in.MarshalCBOR()
+
+	signer, verifier, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+
+	sig, payload, err := in.SignCBORDetached(signer)
+	require.NoError(t, err)
+	assert.Equal(t, c0, payload)
+
+	var msg cose.Sign1Message
+	require.NoError(t, msg.UnmarshalCBOR(sig))
+	assert.Nil(t, msg.Payload)
+
+	var out CMW
+	err = out.VerifyCBORDetached(verifier, sig, payload)
+	assert.NoError(t, err)
+
+	c1, _ := out.MarshalCBOR()
+	assert.Equal(t, c0, c1)
+}
+
+func TestCMW_Signed_CBOR_Detached_failures(t *testing.T) {
+	in := makeCMWCollection()
+
+	signer, verifier, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+
+	sig, payload, err := in.SignCBORDetached(signer)
+	require.NoError(t, err)
+
+	attached, err := in.SignCBOR(signer)
+	require.NoError(t, err)
+
+	tampered := append([]byte{}, payload...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	var c CMW
+
+	err = c.VerifyCBORDetached(verifier, sig, tampered)
+	assert.ErrorContains(t, err, "signed-cbor-cmw signature verification failed: verification error")
+
+	err = c.VerifyCBORDetached(verifier, sig, nil)
+	assert.EqualError(t, err, "empty detached payload")
+
+	err = c.VerifyCBORDetached(verifier, attached, payload)
+	assert.EqualError(t, err, "signed-cbor-cmw has an embedded payload, want detached")
+
+	err = c.VerifyCBOR(verifier, sig)
+	assert.EqualError(t, err, "signed-cbor-cmw has a detached payload")
+}
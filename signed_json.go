@@ -0,0 +1,150 @@
+package cmw
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+// SignJSON produces a signed-json-cmw (i.e., a JWS using the flattened JSON
+// serialization) from the target CMW by signing it with the supplied key using
+// the alg signature algorithm.  The key can be anything accepted by
+// jws.WithKey, e.g., a crypto.Signer or a jwk.Key.
+func (o CMW) SignJSON(alg jwa.SignatureAlgorithm, key any) ([]byte, error) {
+	payload, err := o.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	hdrs, err := signedJSONHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	return jws.Sign(
+		payload,
+		jws.WithKey(alg, key, jws.WithProtectedHeaders(hdrs)),
+		jws.WithJSON(),
+	)
+}
+
+// SignJSONDetached is like SignJSON, except that the returned JWS uses the
+// compact serialization with an empty payload (i.e., "<header>..<signature>"),
+// which makes it suitable for transport in an HTTP header.  The JSON-encoded
+// CMW that has been signed is returned alongside the signature, and must be
+// transported separately.
+func (o CMW) SignJSONDetached(alg jwa.SignatureAlgorithm, key any) ([]byte, []byte, error) {
+	payload, err := o.MarshalJSON()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hdrs, err := signedJSONHeaders()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sig, err := jws.Sign(
+		nil,
+		jws.WithKey(alg, key, jws.WithProtectedHeaders(hdrs)),
+		jws.WithDetachedPayload(payload),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sig, payload, nil
+}
+
+// VerifyJSON verifies the signed-json-cmw using the supplied key and alg.  If
+// the signature is succesfully validated and the payload CMW is correctly
+// formatted, the CMW target is populated.
+func (o *CMW) VerifyJSON(alg jwa.SignatureAlgorithm, key any, b []byte) error {
+	msg, err := decodeSignedJSON(b)
+	if err != nil {
+		return err
+	}
+
+	if len(msg.Payload()) == 0 {
+		return errors.New("signed-json-cmw has a detached payload")
+	}
+
+	payload, err := jws.Verify(b, jws.WithKey(alg, key))
+	if err != nil {
+		return fmt.Errorf("signed-json-cmw signature verification failed: %w", err)
+	}
+
+	if err := o.UnmarshalJSON(payload); err != nil {
+		return fmt.Errorf("JSON decoding signed-json-cmw payload: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyJSONDetached verifies the detached-payload signed-json-cmw in sig over
+// the supplied serialized CMW using the supplied key and alg.  If the signature
+// is successfully validated and the payload deserializes to a correctly
+// formatted CMW, the CMW target is populated.
+func (o *CMW) VerifyJSONDetached(alg jwa.SignatureAlgorithm, key any, sig []byte, payload []byte) error {
+	msg, err := decodeSignedJSON(sig)
+	if err != nil {
+		return err
+	}
+
+	if len(msg.Payload()) != 0 {
+		return errors.New("signed-json-cmw has an embedded payload, want detached")
+	}
+
+	if len(payload) == 0 {
+		return errors.New("empty detached payload")
+	}
+
+	_, err = jws.Verify(sig, jws.WithKey(alg, key), jws.WithDetachedPayload(payload))
+	if err != nil {
+		return fmt.Errorf("signed-json-cmw signature verification failed: %w", err)
+	}
+
+	if err := o.Deserialize(payload); err != nil {
+		return fmt.Errorf("decoding detached signed-json-cmw payload: %w", err)
+	}
+
+	return nil
+}
+
+func signedJSONHeaders() (jws.Headers, error) {
+	hdrs := jws.NewHeaders()
+	if err := hdrs.Set(jws.ContentTypeKey, "application/cmw+json"); err != nil {
+		return nil, fmt.Errorf("setting cty: %w", err)
+	}
+	return hdrs, nil
+}
+
+func decodeSignedJSON(b []byte) (*jws.Message, error) {
+	msg, err := jws.Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("decoding signed-json-cmw: %w", err)
+	}
+
+	sigs := msg.Signatures()
+	if len(sigs) != 1 {
+		return nil, fmt.Errorf("want exactly one signature in signed-json-cmw, got %d", len(sigs))
+	}
+
+	phdr := sigs[0].ProtectedHeaders()
+
+	if v := phdr.ContentType(); v != "" {
+		if v != "application/cmw+json" {
+			return nil, fmt.Errorf("unexpected content type in signed-json-cmw: %v", v)
+		}
+	} else {
+		return nil, fmt.Errorf("missing mandatory cty parameter in signed-json-cmw protected headers")
+	}
+
+	if phdr.Algorithm() == "" {
+		return nil, fmt.Errorf("missing mandatory alg parameter in signed-json-cmw protected headers")
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,132 @@
+package cmw
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getJWSKeys(t *testing.T, keyBytes []byte) (crypto.Signer, crypto.PublicKey) {
+	pkey, err := ParseSigningKey(keyBytes)
+	require.NoError(t, err)
+
+	return pkey, pkey.Public()
+}
+
+func TestCMW_Signed_JSON_roundtrip_ok(t *testing.T) {
+	in := makeCMWCollection()
+	j0, _ := in.MarshalJSON()
+
+	skey, vkey := getJWSKeys(t, testES256Key)
+
+	got, err := in.SignJSON(jwa.ES256, skey)
+	require.NoError(t, err)
+
+	// flattened JSON serialization
+	var flat map[string]any
+	require.NoError(t, json.Unmarshal(got, &flat))
+	assert.Contains(t, flat, "protected")
+	assert.Contains(t, flat, "payload")
+	assert.Contains(t, flat, "signature")
+
+	var out CMW
+	err = out.VerifyJSON(jwa.ES256, vkey, got)
+	assert.NoError(t, err)
+
+	j1, _ := out.MarshalJSON()
+	assert.JSONEq(t, string(j0), string(j1))
+}
+
+func TestCMW_Signed_JSON_Detached_roundtrip_ok(t *testing.T) {
+	in := makeCMWCollection()
+	j0, _ := in.MarshalJSON()
+
+	skey, vkey := getJWSKeys(t, testES256Key)
+
+	sig, payload, err := in.SignJSONDetached(jwa.ES256, skey)
+	require.NoError(t, err)
+	assert.Equal(t, j0, payload)
+
+	// compact serialization with an empty payload
+	parts := bytes.Split(sig, []byte("."))
+	require.Len(t, parts, 3)
+	assert.Empty(t, parts[1])
+
+	var out CMW
+	err = out.VerifyJSONDetached(jwa.ES256, vkey, sig, payload)
+	assert.NoError(t, err)
+
+	j1, _ := out.MarshalJSON()
+	assert.JSONEq(t, string(j0), string(j1))
+}
+
+func TestCMW_Signed_JSON_Detached_failures(t *testing.T) {
+	in := makeCMWCollection()
+
+	skey, vkey := getJWSKeys(t, testES256Key)
+
+	sig, payload, err := in.SignJSONDetached(jwa.ES256, skey)
+	require.NoError(t, err)
+
+	attached, err := in.SignJSON(jwa.ES256, skey)
+	require.NoError(t, err)
+
+	var c CMW
+
+	err = c.VerifyJSONDetached(jwa.ES256, vkey, sig, []byte(`{"a":["application/vnd.a","YQ"]}`))
+	assert.ErrorContains(t, err, "signed-json-cmw signature verification failed")
+
+	err = c.VerifyJSONDetached(jwa.ES256, vkey, sig, nil)
+	assert.EqualError(t, err, "empty detached payload")
+
+	err = c.VerifyJSONDetached(jwa.ES256, vkey, attached, payload)
+	assert.EqualError(t, err, "signed-json-cmw has an embedded payload, want detached")
+
+	err = c.VerifyJSON(jwa.ES256, vkey, sig)
+	assert.EqualError(t, err, "signed-json-cmw has a detached payload")
+}
+
+func TestCMW_Signed_JSON_Verify_phdr_failures(t *testing.T) {
+	skey, vkey := getJWSKeys(t, testES256Key)
+
+	payload, err := makeCMWCollection().MarshalJSON()
+	require.NoError(t, err)
+
+	noCty, err := jws.Sign(payload, jws.WithKey(jwa.ES256, skey), jws.WithJSON())
+	require.NoError(t, err)
+
+	hdrs := jws.NewHeaders()
+	require.NoError(t, hdrs.Set(jws.ContentTypeKey, "application/something+else"))
+	badCty, err := jws.Sign(payload, jws.WithKey(jwa.ES256, skey, jws.WithProtectedHeaders(hdrs)), jws.WithJSON())
+	require.NoError(t, err)
+
+	tvs := []struct {
+		v []byte
+		e string
+	}{
+		{
+			noCty,
+			"missing mandatory cty parameter in signed-json-cmw protected headers",
+		},
+		{
+			badCty,
+			"unexpected content type in signed-json-cmw: application/something+else",
+		},
+		{
+			[]byte(`{"payload":`),
+			"decoding signed-json-cmw",
+		},
+	}
+
+	for _, tv := range tvs {
+		var c CMW
+		err := c.VerifyJSON(jwa.ES256, vkey, tv.v)
+		assert.ErrorContains(t, err, tv.e)
+	}
+}
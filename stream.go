@@ -0,0 +1,626 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// StreamFormat identifies the framing of a stream of CMWs
+type StreamFormat uint
+
+const (
+	// StreamAuto detects the framing from the first byte of the stream.
+	// (Only valid when decoding.)
+	StreamAuto = StreamFormat(iota)
+	// StreamCBORSeq is a CBOR sequence (RFC 8742, application/cbor-seq)
+	StreamCBORSeq
+	// StreamJSONLines is a JSON Lines stream: one JSON-encoded CMW per line
+	StreamJSONLines
+)
+
+func (o StreamFormat) String() string {
+	switch o {
+	case StreamCBORSeq:
+		return "CBOR sequence"
+	case StreamJSONLines:
+		return "JSON Lines"
+	case StreamAuto:
+		fallthrough
+	default:
+		return "auto"
+	}
+}
+
+const (
+	// DefaultMaxItemSize is the default limit on the size of a single CMW
+	// in a stream
+	DefaultMaxItemSize = 64 << 20
+	// DefaultMaxNestedLevels is the default limit on the nesting depth of a
+	// single CMW in a CBOR sequence.  It matches the nesting limit of the
+	// CBOR decoder, which cannot be raised.
+	DefaultMaxNestedLevels = 32
+)
+
+type streamOptions struct {
+	format          StreamFormat
+	maxItemSize     int
+	maxNestedLevels int
+	deserialize     []DeserializeOption
+}
+
+// StreamOption configures a Decoder or an Encoder
+type StreamOption func(*streamOptions)
+
+// WithStreamFormat sets the framing of the stream.  Encoders default to
+// StreamCBORSeq, decoders to StreamAuto.
+func WithStreamFormat(f StreamFormat) StreamOption {
+	return func(o *streamOptions) { o.format = f }
+}
+
+// WithMaxItemSize sets the maximum size in bytes of a single CMW in the
+// stream being decoded
+func WithMaxItemSize(n int) StreamOption {
+	return func(o *streamOptions) { o.maxItemSize = n }
+}
+
+// WithMaxNestedLevels sets the maximum nesting depth of a single CMW in the
+// CBOR sequence being decoded.  Values above DefaultMaxNestedLevels are
+// capped.
+func WithMaxNestedLevels(n int) StreamOption {
+	return func(o *streamOptions) { o.maxNestedLevels = min(n, DefaultMaxNestedLevels) }
+}
+
+// WithStreamDeserializeOptions makes the Decoder unwrap enveloped CMWs (e.g.,
+// signed-cbor-cmw) using the supplied options.  See Deserialize.
+func WithStreamDeserializeOptions(opts ...DeserializeOption) StreamOption {
+	return func(o *streamOptions) { o.deserialize = opts }
+}
+
+// Decoder reads and decodes CMWs from a CBOR sequence or a JSON Lines stream,
+// one at a time, without buffering more than one CMW in memory
+type Decoder struct {
+	r    *bufio.Reader
+	opts streamOptions
+}
+
+// NewDecoder returns a new Decoder that reads from r
+func NewDecoder(r io.Reader, opts ...StreamOption) *Decoder {
+	d := Decoder{
+		r: bufio.NewReader(r),
+		opts: streamOptions{
+			maxItemSize:     DefaultMaxItemSize,
+			maxNestedLevels: DefaultMaxNestedLevels,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+
+	return &d
+}
+
+// Format returns the framing of the stream, which is detected on the first
+// call to Decode unless set with WithStreamFormat
+func (o *Decoder) Format() StreamFormat { return o.opts.format }
+
+// Decode reads the next CMW from the stream and stores it in c.  At the end
+// of the stream, Decode returns io.EOF.
+func (o *Decoder) Decode(c *CMW) error {
+	if c == nil {
+		return errors.New("nil CMW")
+	}
+
+	if o.opts.format == StreamAuto {
+		if err := o.detectFormat(); err != nil {
+			return err
+		}
+	}
+
+	var (
+		item []byte
+		err  error
+	)
+
+	switch o.opts.format {
+	case StreamCBORSeq:
+		item, err = o.readCBORItem()
+	case StreamJSONLines:
+		item, err = o.readLine()
+	default:
+		return fmt.Errorf("unsupported stream format %d", o.opts.format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := c.Deserialize(item, o.opts.deserialize...); err != nil {
+		return fmt.Errorf("decoding %s item: %w", o.opts.format, err)
+	}
+
+	return nil
+}
+
+// detectFormat peeks at the first byte: CMWs in CBOR start with a byte
+// >= 0x80 (array, map or tag), whereas JSON CMWs (and JSON Lines whitespace)
+// are ASCII
+func (o *Decoder) detectFormat() error {
+	b, err := o.r.Peek(1)
+	if err != nil {
+		return err
+	}
+
+	if b[0] >= 0x80 {
+		o.opts.format = StreamCBORSeq
+	} else {
+		o.opts.format = StreamJSONLines
+	}
+
+	return nil
+}
+
+// readLine returns the next non-blank line
+func (o *Decoder) readLine() ([]byte, error) {
+	for {
+		var line []byte
+
+		for {
+			frag, err := o.r.ReadSlice('\n')
+			if len(line)+len(frag) > o.opts.maxItemSize {
+				return nil, fmt.Errorf("JSON Lines item exceeds %d bytes", o.opts.maxItemSize)
+			}
+			line = append(line, frag...)
+
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err == io.EOF && len(line) > 0 {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+// readCBORItem returns the encoding of the next data item in the CBOR
+// sequence, checking the size and nesting limits as it goes
+func (o *Decoder) readCBORItem() ([]byte, error) {
+	// a clean end of stream is only possible between items
+	if _, err := o.r.Peek(1); err != nil {
+		return nil, err
+	}
+
+	cr := cborItemReader{
+		r:         o.r,
+		max:       o.opts.maxItemSize,
+		maxNested: o.opts.maxNestedLevels,
+	}
+
+	if err := cr.item(0); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return cr.buf, nil
+}
+
+type cborItemReader struct {
+	r         *bufio.Reader
+	buf       []byte
+	max       int
+	maxNested int
+}
+
+func (o *cborItemReader) read(n uint64) error {
+	// compare against the remaining budget so that n cannot overflow the sum
+	if n > uint64(o.max-len(o.buf)) {
+		return fmt.Errorf("CBOR sequence item exceeds %d bytes", o.max)
+	}
+
+	start := len(o.buf)
+	o.buf = append(o.buf, make([]byte, n)...)
+
+	_, err := io.ReadFull(o.r, o.buf[start:])
+
+	return err
+}
+
+func (o *cborItemReader) atBreak() (bool, error) {
+	b, err := o.r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+
+	if b[0] != 0xff {
+		return false, nil
+	}
+
+	return true, o.read(1)
+}
+
+func (o *cborItemReader) item(depth int) error {
+	if depth > o.maxNested {
+		return fmt.Errorf("CBOR sequence item exceeds max nesting level %d", o.maxNested)
+	}
+
+	if err := o.read(1); err != nil {
+		return err
+	}
+
+	ib := o.buf[len(o.buf)-1]
+	major, ai := ib>>5, ib&0x1f
+
+	var arg uint64
+
+	switch {
+	case ai < 24:
+		arg = uint64(ai)
+	case ai <= 27:
+		n := uint64(1) << (ai - 24)
+		if err := o.read(n); err != nil {
+			return unexpectedEOF(err)
+		}
+		for _, c := range o.buf[uint64(len(o.buf))-n:] {
+			arg = arg<<8 | uint64(c)
+		}
+	case ai == 31 && major >= 2 && major <= 5:
+		return o.indefinite(major, depth)
+	default:
+		return fmt.Errorf("malformed CBOR initial byte 0x%02x", ib)
+	}
+
+	switch major {
+	case 2, 3:
+		return unexpectedEOF(o.read(arg))
+	case 4, 5:
+		// each element takes at least one byte, which also keeps the
+		// doubling for maps from overflowing
+		if arg > uint64(o.max-len(o.buf)) {
+			return fmt.Errorf("CBOR sequence item exceeds %d bytes", o.max)
+		}
+		if major == 5 {
+			arg *= 2
+		}
+		for i := uint64(0); i < arg; i++ {
+			if err := o.item(depth + 1); err != nil {
+				return unexpectedEOF(err)
+			}
+		}
+	case 6:
+		return unexpectedEOF(o.item(depth + 1))
+	}
+
+	return nil
+}
+
+// indefinite reads the chunks or elements of an indefinite-length item up to
+// and including the "break" stop code
+func (o *cborItemReader) indefinite(major byte, depth int) error {
+	for {
+		brk, err := o.atBreak()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if brk {
+			return nil
+		}
+
+		n := 1
+		if major == 5 {
+			n = 2
+		}
+
+		for range n {
+			if err := o.item(depth + 1); err != nil {
+				return unexpectedEOF(err)
+			}
+		}
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Encoder writes CMWs to a stream, either as a CBOR sequence or as JSON
+// Lines
+type Encoder struct {
+	w    io.Writer
+	opts streamOptions
+}
+
+// NewEncoder returns a new Encoder that writes to w.  Unless a different
+// framing is chosen with WithStreamFormat, a CBOR sequence is produced.
+func NewEncoder(w io.Writer, opts ...StreamOption) *Encoder {
+	e := Encoder{
+		w:    w,
+		opts: streamOptions{format: StreamCBORSeq},
+	}
+
+	for _, opt := range opts {
+		opt(&e.opts)
+	}
+
+	return &e
+}
+
+// Encode writes c to the stream.  Monad values are copied to the stream
+// incrementally (see WriteCBOR and WriteJSON), so an error may leave a
+// partially written item behind.
+func (o *Encoder) Encode(c *CMW) error {
+	if c == nil {
+		return errors.New("nil CMW")
+	}
+
+	var err error
+
+	switch o.opts.format {
+	case StreamCBORSeq:
+		err = c.WriteCBOR(o.w)
+	case StreamJSONLines:
+		if err = c.WriteJSON(o.w); err == nil {
+			_, err = o.w.Write([]byte{'\n'})
+		}
+	default:
+		return fmt.Errorf("unsupported stream format %d", o.opts.format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("writing %s item: %w", o.opts.format, err)
+	}
+
+	return nil
+}
+
+// WriteCBOR writes the CBOR serialization of the CMW to w.  The output is
+// identical to that of MarshalCBOR, but monad values are copied to w
+// incrementally, so that values backed by a reader (see NewMonadFromReader)
+// are never held in memory in full.
+func (o CMW) WriteCBOR(w io.Writer) error {
+	if err := o.checkValues(); err != nil {
+		return err
+	}
+
+	switch o.kind {
+	case KindMonad:
+		return o.monad.writeCBOR(w)
+	case KindCollection:
+		return o.collection.writeCBOR(w)
+	default:
+		return errors.New("unknown CMW kind")
+	}
+}
+
+// WriteJSON writes the JSON serialization of the CMW to w.  The output is
+// identical to that of MarshalJSON, but monad values are base64url-encoded
+// and copied to w incrementally.
+func (o CMW) WriteJSON(w io.Writer) error {
+	if err := o.checkValues(); err != nil {
+		return err
+	}
+
+	switch o.kind {
+	case KindMonad:
+		return o.monad.writeJSON(w)
+	case KindCollection:
+		return o.collection.writeJSON(w)
+	default:
+		return errors.New("unknown CMW kind")
+	}
+}
+
+func writeAll(w io.Writer, parts ...[]byte) error {
+	for _, p := range parts {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o monad) writeCBOR(w io.Writer) error {
+	if !o.typ.IsSet() || !o.hasValue() {
+		return errors.New("type and value MUST be set in CMW")
+	}
+
+	var hdr []byte
+
+	switch o.format {
+	case FormatCBORTag:
+		tn, err := o.typ.TagNumber()
+		if err != nil {
+			return fmt.Errorf("getting a suitable tag value: %w", err)
+		}
+		hdr = appendHead(hdr, 6, tn)
+	default:
+		n := uint64(2)
+		if !o.ind.Empty() {
+			n = 3
+		}
+		t, err := em.Marshal(o.typ)
+		if err != nil {
+			return fmt.Errorf("marshaling type: %w", err)
+		}
+		hdr = append(appendHead(hdr, 4, n), t...)
+	}
+
+	hdr = appendHead(hdr, 2, uint64(o.valueSize()))
+
+	if err := writeAll(w, hdr); err != nil {
+		return err
+	}
+
+	if err := o.copyValue(w); err != nil {
+		return err
+	}
+
+	if o.format == FormatCBORTag || o.ind.Empty() {
+		return nil
+	}
+
+	ind, err := em.Marshal(o.ind)
+	if err != nil {
+		return fmt.Errorf("marshaling indicator: %w", err)
+	}
+
+	return writeAll(w, ind)
+}
+
+func (o monad) writeJSON(w io.Writer) error {
+	if !o.typ.IsSet() || !o.hasValue() {
+		return errors.New("type and value MUST be set in CMW")
+	}
+
+	t, err := json.Marshal(o.typ)
+	if err != nil {
+		return fmt.Errorf("marshaling type: %w", err)
+	}
+
+	if err := writeAll(w, []byte("["), t, []byte(`,"`)); err != nil {
+		return err
+	}
+
+	enc := base64.NewEncoder(base64.RawURLEncoding, w)
+
+	if err := o.copyValue(enc); err != nil {
+		return err
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if err := writeAll(w, []byte(`"`)); err != nil {
+		return err
+	}
+
+	if !o.ind.Empty() {
+		ind, err := json.Marshal(o.ind)
+		if err != nil {
+			return fmt.Errorf("marshaling indicator: %w", err)
+		}
+		if err := writeAll(w, []byte(","), ind); err != nil {
+			return err
+		}
+	}
+
+	return writeAll(w, []byte("]"))
+}
+
+type encodedKey struct {
+	key any
+	enc []byte
+}
+
+// writeCBOR writes the collection with its keys in the same (bytewise
+// lexicographic) order used by the deterministic encoder
+func (o collection) writeCBOR(w io.Writer) error {
+	keys := make([]encodedKey, 0, len(o.cmap)+1)
+
+	if o.ctyp != "" {
+		k, _ := em.Marshal(CmwCType)
+		keys = append(keys, encodedKey{CmwCType, k})
+	}
+
+	for k := range o.cmap {
+		enc, err := em.Marshal(k)
+		if err != nil {
+			return fmt.Errorf("marshaling CBOR collection key %v: %w", k, err)
+		}
+		keys = append(keys, encodedKey{k, enc})
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i].enc, keys[j].enc) < 0 })
+
+	if err := writeAll(w, appendHead(nil, 5, uint64(len(keys)))); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := writeAll(w, k.enc); err != nil {
+			return err
+		}
+
+		if o.ctyp != "" && k.key == CmwCType {
+			ct, _ := em.Marshal(o.ctyp)
+			if err := writeAll(w, ct); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := o.cmap[k.key].WriteCBOR(w); err != nil {
+			return fmt.Errorf("writing CBOR collection item %v: %w", k.key, err)
+		}
+	}
+
+	return nil
+}
+
+func (o collection) writeJSON(w io.Writer) error {
+	keys := make([]string, 0, len(o.cmap)+1)
+
+	if o.ctyp != "" {
+		keys = append(keys, CmwCType)
+	}
+
+	for k := range o.cmap {
+		s, ok := k.(string)
+		if !ok {
+			return fmt.Errorf("JSON collection, key error: want string, got %T", k)
+		}
+		keys = append(keys, s)
+	}
+
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		sep := ","
+		if i == 0 {
+			sep = "{"
+		}
+
+		enc, _ := json.Marshal(k)
+
+		if err := writeAll(w, []byte(sep), enc, []byte(":")); err != nil {
+			return err
+		}
+
+		if o.ctyp != "" && k == CmwCType {
+			ct, _ := json.Marshal(o.ctyp)
+			if err := writeAll(w, ct); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := o.cmap[k].WriteJSON(w); err != nil {
+			return fmt.Errorf("writing JSON collection item %v: %w", k, err)
+		}
+	}
+
+	if len(keys) == 0 {
+		return writeAll(w, []byte("{}"))
+	}
+
+	return writeAll(w, []byte("}"))
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cose "github.com/veraison/go-cose"
+)
+
+func makeStreamTestCMWs(t *testing.T) []*CMW {
+	record := mustNewMonad(t, "application/vnd.a", []byte{0x61}, Evidence)
+
+	var collection CMW
+	require.NoError(t, collection.UnmarshalJSON(mustReadFile(t, "testdata/collection-ok.json")))
+
+	return []*CMW{record, &collection}
+}
+
+func Test_Stream_roundtrip(t *testing.T) {
+	tag := mustNewMonad(t, uint16(30001), []byte{0x23, 0x47, 0xda, 0x55})
+	tag.UseCBORTagFormat()
+
+	tests := []struct {
+		format StreamFormat
+		extra  []*CMW
+		kinds  []Kind
+	}{
+		{StreamCBORSeq, []*CMW{tag}, []Kind{KindMonad, KindCollection, KindMonad}},
+		{StreamJSONLines, nil, []Kind{KindMonad, KindCollection}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc := NewEncoder(&buf, WithStreamFormat(tt.format))
+			for _, c := range append(makeStreamTestCMWs(t), tt.extra...) {
+				require.NoError(t, enc.Encode(c))
+			}
+
+			dec := NewDecoder(&buf)
+			assert.Equal(t, StreamAuto, dec.Format())
+
+			for _, kind := range tt.kinds {
+				var c CMW
+				require.NoError(t, dec.Decode(&c))
+				assert.Equal(t, kind, c.GetKind())
+			}
+
+			assert.Equal(t, tt.format, dec.Format())
+
+			var c CMW
+			assert.Equal(t, io.EOF, dec.Decode(&c))
+		})
+	}
+}
+
+func Test_Decoder_JSON_Lines_blank_lines(t *testing.T) {
+	stream := "\n" + `["application/vnd.a","YQ"]` + "\r\n\n  \n" + `{"a":["application/vnd.b","Yg",4]}`
+
+	dec := NewDecoder(strings.NewReader(stream))
+
+	var c CMW
+	require.NoError(t, dec.Decode(&c))
+	assert.Equal(t, FormatJSONRecord, c.GetFormat())
+
+	require.NoError(t, dec.Decode(&c))
+	assert.Equal(t, FormatJSONCollection, c.GetFormat())
+
+	assert.Equal(t, io.EOF, dec.Decode(&c))
+}
+
+func Test_Decoder_signed_items(t *testing.T) {
+	signer, verifier, err := getCOSESignerAndVerifier(t, testES256Key, cose.AlgorithmES256)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	for _, c := range makeStreamTestCMWs(t) {
+		b, err := c.SignCBOR(signer)
+		require.NoError(t, err)
+		buf.Write(b)
+	}
+
+	dec := NewDecoder(bytes.NewReader(buf.Bytes()), WithStreamDeserializeOptions(WithCOSEVerifier(verifier)))
+
+	var c CMW
+	require.NoError(t, dec.Decode(&c))
+	assert.Equal(t, KindMonad, c.GetKind())
+	require.NoError(t, dec.Decode(&c))
+	assert.Equal(t, KindCollection, c.GetKind())
+	assert.Equal(t, io.EOF, dec.Decode(&c))
+
+	// without a verifier, envelopes are not removed
+	dec = NewDecoder(bytes.NewReader(buf.Bytes()))
+	assert.EqualError(t, dec.Decode(&c), "decoding CBOR sequence item: unknown start symbol for CMW: 0xd2")
+}
+
+func Test_Stream_large_collection_via_pipe(t *testing.T) {
+	const n = 2000
+
+	big, err := NewCollection("tag:example.com,2025:big")
+	require.NoError(t, err)
+
+	for i := range n {
+		m := mustNewMonad(t, "application/vnd.x", bytes.Repeat([]byte{byte(i)}, 512))
+		require.NoError(t, big.AddCollectionItem(uint64(i), m))
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		enc := NewEncoder(pw)
+		for range 3 {
+			if err := enc.Encode(big); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.Close()
+	}()
+
+	dec := NewDecoder(pr)
+
+	for range 3 {
+		var c CMW
+		require.NoError(t, dec.Decode(&c))
+		meta, err := c.GetCollectionMeta()
+		require.NoError(t, err)
+		assert.Len(t, meta, n)
+	}
+
+	var c CMW
+	assert.Equal(t, io.EOF, dec.Decode(&c))
+}
+
+func Test_Decoder_limits(t *testing.T) {
+	// 33 nested arrays
+	deep := append(bytes.Repeat([]byte{0x81}, 33), 0x00)
+
+	tests := []struct {
+		name   string
+		stream []byte
+		opts   []StreamOption
+		err    string
+	}{
+		{"CBOR too large", mustReadFile(t, "testdata/collection-cbor-ok.cbor"), []StreamOption{WithMaxItemSize(16)}, "CBOR sequence item exceeds 16 bytes"},
+		{"JSON too large", []byte(`{"a":["application/vnd.a","YQ"]}` + "\n"), []StreamOption{WithMaxItemSize(16)}, "JSON Lines item exceeds 16 bytes"},
+		{"CBOR too deep", deep, nil, "CBOR sequence item exceeds max nesting level 32"},
+		{"CBOR too deep for option", []byte{0x81, 0x81, 0x81, 0x00}, []StreamOption{WithMaxNestedLevels(2)}, "CBOR sequence item exceeds max nesting level 2"},
+		{"CBOR too deep above default", deep, []StreamOption{WithMaxNestedLevels(64)}, "CBOR sequence item exceeds max nesting level 32"},
+		{"CBOR huge length", []byte{0x82, 0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, nil, "CBOR sequence item exceeds 67108864 bytes"},
+		{"CBOR huge map", []byte{0xbb, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}, nil, "CBOR sequence item exceeds 67108864 bytes"},
+		{"CBOR truncated", []byte{0x82, 0x61}, nil, "unexpected EOF"},
+		{"CBOR truncated indefinite", []byte{0x9f, 0x01}, nil, "unexpected EOF"},
+		{"CBOR malformed", []byte{0xfc}, nil, "malformed CBOR initial byte 0xfc"},
+		{"not a CMW", []byte{0x01}, []StreamOption{WithStreamFormat(StreamCBORSeq)}, "decoding CBOR sequence item: unknown start symbol for CMW: 0x01"},
+		{"bad JSON", []byte("[1,2]\n"), nil, "decoding JSON Lines item: unmarshaling value: cannot decode value: want JSON string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c CMW
+			err := NewDecoder(bytes.NewReader(tt.stream), tt.opts...).Decode(&c)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func Test_Encoder_fail(t *testing.T) {
+	var c CMW
+	require.NoError(t, c.UnmarshalCBOR(mustReadFile(t, "testdata/collection-cbor-ok.cbor")))
+
+	enc := NewEncoder(io.Discard, WithStreamFormat(StreamJSONLines))
+	assert.EqualError(t, enc.Encode(&c), "writing JSON Lines item: JSON collection, key error: want string, got uint64")
+
+	enc = NewEncoder(io.Discard, WithStreamFormat(StreamAuto))
+	assert.EqualError(t, enc.Encode(&c), "unsupported stream format 0")
+
+	assert.EqualError(t, enc.Encode(nil), "nil CMW")
+
+	enc = NewEncoder(failingWriter{})
+	assert.EqualError(t, enc.Encode(&c), "writing CBOR sequence item: boom")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, fmt.Errorf("boom") }
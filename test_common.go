@@ -1,13 +1,6 @@
 package cmw
 
 import (
-	"crypto"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"encoding/base64"
-	"encoding/json"
-	"errors"
-	"math/big"
 	"testing"
 
 	cose "github.com/veraison/go-cose"
@@ -23,14 +16,7 @@ var testES256Key = []byte(`{
 }`)
 
 func getCOSESignerAndVerifier(t *testing.T, keyBytes []byte, alg cose.Algorithm) (cose.Signer, cose.Verifier, error) {
-	var key map[string]string
-
-	err := json.Unmarshal(keyBytes, &key)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	pkey, err := getKey(key)
+	pkey, err := ParseSigningKey(keyBytes)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -47,38 +33,3 @@ func getCOSESignerAndVerifier(t *testing.T, keyBytes []byte, alg cose.Algorithm)
 
 	return signer, verifier, nil
 }
-
-func getKey(key map[string]string) (crypto.Signer, error) {
-	switch key["kty"] {
-	case "EC":
-		var c elliptic.Curve
-		switch key["crv"] {
-		case "P-256":
-			c = elliptic.P256()
-		case "P-384":
-			c = elliptic.P384()
-		case "P-521":
-			c = elliptic.P521()
-		default:
-			return nil, errors.New("unsupported EC curve: " + key["crv"])
-		}
-		pkey := &ecdsa.PrivateKey{
-			PublicKey: ecdsa.PublicKey{
-				X:     mustBase64ToBigInt(key["x"]),
-				Y:     mustBase64ToBigInt(key["y"]),
-				Curve: c,
-			},
-			D: mustBase64ToBigInt(key["d"]),
-		}
-		return pkey, nil
-	}
-	return nil, errors.New("unsupported key type: " + key["kty"])
-}
-
-func mustBase64ToBigInt(s string) *big.Int {
-	val, err := base64.RawURLEncoding.DecodeString(s)
-	if err != nil {
-		panic(err)
-	}
-	return new(big.Int).SetBytes(val)
-}
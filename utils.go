@@ -3,6 +3,9 @@ package cmw
 import (
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
 	"regexp"
 )
 
@@ -40,3 +43,46 @@ func startJSONRecord(c byte) bool     { return c == 0x5b }
 func startCBORCollection(c byte) bool { return c >= 0xa0 && c <= 0xbb || c == 0xbf }
 func startCBORRecord(c byte) bool     { return c == 0x82 || c == 0x83 || c == 0x9f }
 func startCBORTag(c byte) bool        { return c >= 0xda }
+
+// checkCBORTagHead checks that b holds the complete head of a CBOR tag with a
+// 4 or 8 bytes tag number
+func checkCBORTagHead(b []byte) error {
+	var n int
+
+	switch {
+	case len(b) == 0:
+		return errors.New("empty input")
+	case b[0] == 0xda:
+		n = 5
+	case b[0] == 0xdb:
+		n = 9
+	default:
+		return fmt.Errorf("want 4 or 8 bytes CBOR tag, got initial byte 0x%02x", b[0])
+	}
+
+	if len(b) < n {
+		return fmt.Errorf("truncated CBOR tag: want at least %d bytes, got %d", n, len(b))
+	}
+
+	return nil
+}
+
+// appendHead appends the initial byte(s) of a CBOR data item with the given
+// major type and argument
+func appendHead(out []byte, major byte, arg uint64) []byte {
+	m := major << 5
+
+	switch {
+	case arg < 24:
+		return append(out, m|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(out, m|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return append(out, m|25, byte(arg>>8), byte(arg))
+	case arg <= math.MaxUint32:
+		return append(out, m|26, byte(arg>>24), byte(arg>>16), byte(arg>>8), byte(arg))
+	default:
+		return append(out, m|27, byte(arg>>56), byte(arg>>48), byte(arg>>40), byte(arg>>32),
+			byte(arg>>24), byte(arg>>16), byte(arg>>8), byte(arg))
+	}
+}
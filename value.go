@@ -29,6 +29,10 @@ func (o *Value) UnmarshalJSON(b []byte) error {
 		err error
 	)
 
+	if len(b) < 2 {
+		return errors.New("cannot decode value: want JSON string")
+	}
+
 	if v, err = b64uDecode(string(b[1 : len(b)-1])); err != nil {
 		return fmt.Errorf("cannot base64 url-safe decode: %w", err)
 	}
@@ -0,0 +1,165 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// valueSource is a monad value that is read on demand rather than held in
+// memory
+type valueSource struct {
+	r    io.Reader
+	size int64
+
+	// if r is seekable, it is rewound to start before each use, so that
+	// the value can be read more than once
+	seeker io.Seeker
+	start  int64
+
+	// set once a reader that is not seekable has been handed out
+	consumed bool
+}
+
+var errValueConsumed = errors.New("monad value reader already consumed: readers that are not an io.Seeker can only be read once")
+
+// check fails if the value can no longer be read
+func (o *valueSource) check() error {
+	if o.seeker == nil && o.consumed {
+		return errValueConsumed
+	}
+	return nil
+}
+
+func (o *valueSource) reader() (io.Reader, error) {
+	if err := o.check(); err != nil {
+		return nil, err
+	}
+
+	if o.seeker != nil {
+		if _, err := o.seeker.Seek(o.start, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("rewinding value reader: %w", err)
+		}
+	} else {
+		o.consumed = true
+	}
+
+	return io.LimitReader(o.r, o.size), nil
+}
+
+// NewMonadFromReader instantiates a monad whose value is the next size bytes
+// read from r.  The value is never loaded in memory in full by WriteCBOR,
+// WriteJSON and the streaming Encoder, which copy it to their output
+// incrementally.  If r is an io.Seeker, it is rewound before each use;
+// otherwise, the value can only be read once: any further attempt to
+// serialize the monad (or to read its value) fails before anything is
+// written.
+func NewMonadFromReader(mediaType any, r io.Reader, size int64, indicators ...Indicator) (*CMW, error) {
+	if r == nil {
+		return nil, errors.New("nil reader")
+	}
+
+	if size <= 0 {
+		return nil, errors.New("empty value")
+	}
+
+	var c CMW
+
+	if err := c.typ.Set(mediaType); err != nil {
+		return nil, err
+	}
+
+	if err := c.setIndicators(indicators...); err != nil {
+		return nil, err
+	}
+
+	src := valueSource{r: r, size: size}
+
+	if s, ok := r.(io.Seeker); ok {
+		if off, err := s.Seek(0, io.SeekCurrent); err == nil {
+			src.seeker, src.start = s, off
+		}
+	}
+
+	c.src = &src
+	c.kind = KindMonad
+
+	return &c, nil
+}
+
+// GetMonadValueReader returns a reader for the monad value and its size.  It
+// works for both in-memory and reader-backed values (see NewMonadFromReader).
+// For the latter, the returned reader is backed by the reader supplied on
+// creation, so it is not safe for concurrent use.
+//
+// Decoding is not streamed: the values of deserialized CMWs are always held
+// in memory in full, and the returned reader merely reads from that copy.
+func (o CMW) GetMonadValueReader() (io.Reader, int64, error) {
+	if o.kind != KindMonad {
+		return nil, 0, fmt.Errorf("want monad, got %q", o.kind)
+	}
+
+	r, err := o.monad.valueReader()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return r, o.monad.valueSize(), nil
+}
+
+// checkValues fails if any value backed by a reader can no longer be read, so
+// that streaming serializations do not fail after writing a partial output
+func (o CMW) checkValues() error {
+	switch o.kind {
+	case KindMonad:
+		if o.monad.src != nil {
+			return o.monad.src.check()
+		}
+	case KindCollection:
+		for _, v := range o.collection.cmap {
+			if err := v.checkValues(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (o monad) hasValue() bool { return o.src != nil || o.val.IsSet() }
+
+func (o monad) valueSize() int64 {
+	if o.src != nil {
+		return o.src.size
+	}
+	return int64(len(o.val))
+}
+
+func (o monad) valueReader() (io.Reader, error) {
+	if o.src != nil {
+		return o.src.reader()
+	}
+	return bytes.NewReader(o.val), nil
+}
+
+// copyValue copies exactly valueSize() bytes of the value to w
+func (o monad) copyValue(w io.Writer) error {
+	r, err := o.valueReader()
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return fmt.Errorf("copying value: %w", err)
+	}
+
+	if n != o.valueSize() {
+		return fmt.Errorf("copying value: want %d bytes, got %d", o.valueSize(), n)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteCBOR_WriteJSON_match_Marshal(t *testing.T) {
+	tag, err := NewMonad(uint16(30001), []byte{0x23, 0x47, 0xda, 0x55})
+	require.NoError(t, err)
+	tag.UseCBORTagFormat()
+
+	record, err := NewMonad("application/vnd.a", []byte{0x61}, Evidence)
+	require.NoError(t, err)
+
+	tvs := map[string]*CMW{"tag": tag, "record": record}
+
+	for _, f := range []string{
+		"testdata/collection-cbor-ok.cbor",
+		"testdata/collection-cbor-ok-2.cbor",
+		"testdata/collection-cbor-mixed-keys.cbor",
+		"testdata/collection-ok.json",
+	} {
+		var c CMW
+		require.NoError(t, c.Deserialize(mustReadFile(t, f)))
+		tvs[f] = &c
+	}
+
+	for name, c := range tvs {
+		t.Run(name, func(t *testing.T) {
+			exp, err := c.MarshalCBOR()
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, c.WriteCBOR(&buf))
+			assert.Equal(t, exp, buf.Bytes())
+
+			exp, err = c.MarshalJSON()
+			if err != nil {
+				assert.Error(t, c.WriteJSON(io.Discard))
+				return
+			}
+
+			buf.Reset()
+			require.NoError(t, c.WriteJSON(&buf))
+			assert.Equal(t, exp, buf.Bytes())
+		})
+	}
+}
+
+func Test_NewMonadFromReader_large(t *testing.T) {
+	const size = 4 << 20
+
+	value := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+
+	c, err := NewMonadFromReader("application/octet-stream", bytes.NewReader(value), size, Evidence)
+	require.NoError(t, err)
+
+	inMemory, err := NewMonad("application/octet-stream", value, Evidence)
+	require.NoError(t, err)
+
+	// the reader is seekable, so the value can be serialized more than once
+	for _, format := range []StreamFormat{StreamCBORSeq, StreamJSONLines} {
+		var actual, expected bytes.Buffer
+
+		require.NoError(t, NewEncoder(&actual, WithStreamFormat(format)).Encode(c))
+		require.NoError(t, NewEncoder(&expected, WithStreamFormat(format)).Encode(inMemory))
+
+		assert.Equal(t, sha256.Sum256(expected.Bytes()), sha256.Sum256(actual.Bytes()), format.String())
+	}
+
+	var decoded CMW
+	require.NoError(t, decoded.UnmarshalCBOR(mustMarshalCBOR(t, c)))
+
+	r, n, err := decoded.GetMonadValueReader()
+	require.NoError(t, err)
+	assert.EqualValues(t, size, n)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, value, b)
+}
+
+func Test_NewMonadFromReader_tag(t *testing.T) {
+	c, err := NewMonadFromReader(uint16(30001), strings.NewReader("\x23\x47\xda\x55"), 4)
+	require.NoError(t, err)
+	c.UseCBORTagFormat()
+
+	b, err := c.MarshalCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xda, 0x63, 0x74, 0x76, 0xa7, 0x44, 0x23, 0x47, 0xda, 0x55}, b)
+}
+
+func Test_NewMonadFromReader_not_seekable(t *testing.T) {
+	value := []byte("a value that can only be read once")
+
+	c, err := NewMonadFromReader("text/plain", io.MultiReader(bytes.NewReader(value)), int64(len(value)))
+	require.NoError(t, err)
+
+	_, err = c.MarshalCBOR()
+	assert.NoError(t, err)
+
+	// further serializations fail before writing anything
+	var buf bytes.Buffer
+	assert.EqualError(t, c.WriteCBOR(&buf), "monad value reader already consumed: readers that are not an io.Seeker can only be read once")
+	assert.EqualError(t, c.WriteJSON(&buf), "monad value reader already consumed: readers that are not an io.Seeker can only be read once")
+	assert.Zero(t, buf.Len())
+
+	coll, err := NewCollection("")
+	require.NoError(t, err)
+	require.NoError(t, coll.AddCollectionItem("a", c))
+	require.NoError(t, coll.AddCollectionItem("b", mustNewMonad(t, "text/plain", []byte("b"))))
+	assert.EqualError(t, NewEncoder(&buf).Encode(coll), "writing CBOR sequence item: monad value reader already consumed: readers that are not an io.Seeker can only be read once")
+	assert.Zero(t, buf.Len())
+
+	_, _, err = c.GetMonadValueReader()
+	assert.EqualError(t, err, "monad value reader already consumed: readers that are not an io.Seeker can only be read once")
+}
+
+func Test_NewMonadFromReader_short(t *testing.T) {
+	c, err := NewMonadFromReader("text/plain", strings.NewReader("short"), 10)
+	require.NoError(t, err)
+
+	_, err = c.MarshalCBOR()
+	assert.EqualError(t, err, "copying value: want 10 bytes, got 5")
+}
+
+func Test_NewMonadFromReader_ko(t *testing.T) {
+	_, err := NewMonadFromReader("text/plain", nil, 1)
+	assert.EqualError(t, err, "nil reader")
+
+	_, err = NewMonadFromReader("text/plain", strings.NewReader("x"), 0)
+	assert.EqualError(t, err, "empty value")
+
+	_, err = NewMonadFromReader(nil, strings.NewReader("x"), 1)
+	assert.Error(t, err)
+
+	_, err = NewMonadFromReader("text/plain", strings.NewReader("x"), 1, Indicator(32))
+	assert.EqualError(t, err, "indicator 32 has undefined bits set (0x20), max registered value is 31")
+}
+
+func Test_GetMonadValue_reader_backed(t *testing.T) {
+	c, err := NewMonadFromReader("text/plain", strings.NewReader("x"), 1)
+	require.NoError(t, err)
+
+	_, err = c.GetMonadValue()
+	assert.EqualError(t, err, "monad value is backed by a reader: use GetMonadValueReader")
+
+	assert.Equal(t, "monad (unknown) text/plain, 1 bytes: <streamed>", fmt.Sprint(c))
+}
+
+func Test_GetMonadValueReader_collection(t *testing.T) {
+	c, err := NewCollection("")
+	require.NoError(t, err)
+
+	_, _, err = c.GetMonadValueReader()
+	assert.EqualError(t, err, `want monad, got "collection"`)
+}
+
+func mustMarshalCBOR(t *testing.T, c *CMW) []byte {
+	b, err := c.MarshalCBOR()
+	require.NoError(t, err)
+	return b
+}
@@ -0,0 +1,17 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Value_UnmarshalJSON_fail_short(t *testing.T) {
+	var v Value
+
+	err := v.UnmarshalJSON([]byte(`1`))
+	assert.EqualError(t, err, "cannot decode value: want JSON string")
+}
@@ -63,29 +63,53 @@ func DecodeX509Extension(extn pkix.Extension) (*CMW, error) {
 		return nil, fmt.Errorf("expecting id-pe-cmw (1.3.6.1.5.5.7.1.35), got %s", extn.Id)
 	}
 
+	var cmw CMW
+
+	serializedCmw, choice, err := x509ExtensionPayload(extn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch choice {
+	case ChoiceCbor:
+		err = cmw.UnmarshalCBOR(serializedCmw)
+	case ChoiceJson:
+		err = cmw.UnmarshalJSON(serializedCmw)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decoding the wrapped CMW: %w", err)
+	}
+
+	return &cmw, nil
+}
+
+// x509ExtensionPayload returns the serialized CMW wrapped in the supplied
+// id-pe-cmw extension value, and whether it is CBOR (OCTET STRING) or JSON
+// (UTF8String) encoded.
+func x509ExtensionPayload(extn pkix.Extension) ([]byte, Choice, error) {
 	var (
 		serializedCmwWrapper any
-		cmw                  CMW
-		err                  error
+		serializedCmw        []byte
+		choice               Choice
 	)
 
-	_, err = asn1.Unmarshal(extn.Value, &serializedCmwWrapper)
-	if err != nil {
-		return nil, fmt.Errorf("unmarshalling the extension value: %w", err)
+	if _, err := asn1.Unmarshal(extn.Value, &serializedCmwWrapper); err != nil {
+		return nil, choice, fmt.Errorf("unmarshalling the extension value: %w", err)
 	}
 
 	switch t := serializedCmwWrapper.(type) {
 	case []byte:
-		err = cmw.UnmarshalCBOR(t)
+		serializedCmw, choice = t, ChoiceCbor
 	case string:
-		err = cmw.UnmarshalJSON([]byte(t))
+		serializedCmw, choice = []byte(t), ChoiceJson
 	default:
-		return nil, fmt.Errorf("expecting OCTET STRING or UTF8String, got %T", t)
+		return nil, choice, fmt.Errorf("expecting OCTET STRING or UTF8String, got %T", t)
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("decoding the wrapped CMW: %w", err)
+	if len(serializedCmw) == 0 {
+		return nil, choice, fmt.Errorf("decoding the wrapped CMW: %w", errors.New("empty buffer"))
 	}
 
-	return &cmw, nil
+	return serializedCmw, choice, nil
 }
@@ -0,0 +1,268 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LayerAnomaly is a bit map of the irregularities detected while extracting
+// CMWs from a certificate chain
+type LayerAnomaly uint
+
+const (
+	// the id-pe-cmw extension is marked critical
+	LayerAnomalyCritical = LayerAnomaly(1 << iota)
+	// the certificate has more than one id-pe-cmw extension.  Since
+	// x509.ParseCertificate rejects duplicate extensions, this is only set
+	// for *x509.Certificate values built by hand.
+	LayerAnomalyDuplicate
+	// the id-pe-cmw extension has the same value as one found in another
+	// certificate of the chain
+	LayerAnomalyRepeated
+)
+
+const LayerAnomalyNone = LayerAnomaly(0)
+
+var layerAnomalyMap = map[LayerAnomaly]string{
+	LayerAnomalyCritical:  "critical",
+	LayerAnomalyDuplicate: "duplicate",
+	LayerAnomalyRepeated:  "repeated",
+}
+
+func (o LayerAnomaly) Has(v LayerAnomaly) bool { return o&v != 0 }
+func (o LayerAnomaly) String() string {
+	var a []string
+
+	for _, k := range []LayerAnomaly{
+		LayerAnomalyCritical, LayerAnomalyDuplicate, LayerAnomalyRepeated,
+	} {
+		if o.Has(k) {
+			a = append(a, layerAnomalyMap[k])
+		}
+	}
+
+	return strings.Join(a, ", ")
+}
+
+// CMWLayer is a CMW extracted from one certificate of a DICE chain.  Layer is
+// the DICE layer number (0 is the root of the chain), CertIndex is the position
+// of the certificate in the leaf-to-root chain supplied to DecodeX509Chain, and
+// Occurrence distinguishes multiple id-pe-cmw extensions in the same
+// certificate.
+type CMWLayer struct {
+	Layer      int
+	CertIndex  int
+	Occurrence int
+	Cert       *x509.Certificate
+	CMW        *CMW
+	Anomalies  LayerAnomaly
+}
+
+// LayeredCMW is the ordered (root to leaf) list of CMWs found in a certificate
+// chain
+type LayeredCMW []CMWLayer
+
+// DecodeX509Chain decodes the CMWs carried in the id-pe-cmw extensions of the
+// supplied (leaf-to-root) certificate chain.  Certificates without a CMW
+// extension do not contribute any layer, but are still counted for layer
+// numbering.  Extensions that cannot be decoded cause an error.
+func DecodeX509Chain(chain []*x509.Certificate) (LayeredCMW, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("empty certificate chain")
+	}
+
+	var (
+		layers LayeredCMW
+		seen   = make(map[string]int) // extension value -> cert index
+	)
+
+	for layer := 0; layer < len(chain); layer++ {
+		idx := len(chain) - 1 - layer
+		cert := chain[idx]
+
+		if cert == nil {
+			return nil, fmt.Errorf("certificate %d: nil certificate", idx)
+		}
+
+		var found []CMWLayer
+
+		for _, extn := range cert.Extensions {
+			if !extn.Id.Equal(OidExtCmw) {
+				continue
+			}
+
+			c, err := DecodeX509Extension(extn)
+			if err != nil {
+				return nil, fmt.Errorf("certificate %d: %w", idx, err)
+			}
+
+			l := CMWLayer{
+				Layer:      layer,
+				CertIndex:  idx,
+				Occurrence: len(found),
+				Cert:       cert,
+				CMW:        c,
+			}
+
+			if extn.Critical {
+				l.Anomalies |= LayerAnomalyCritical
+			}
+
+			if other, ok := seen[string(extn.Value)]; ok && other != idx {
+				l.Anomalies |= LayerAnomalyRepeated
+			}
+			seen[string(extn.Value)] = idx
+
+			found = append(found, l)
+		}
+
+		if len(found) > 1 {
+			for i := range found {
+				found[i].Anomalies |= LayerAnomalyDuplicate
+			}
+		}
+
+		layers = append(layers, found...)
+	}
+
+	return layers, nil
+}
+
+// Anomalies returns the union of the anomalies detected across all layers
+func (o LayeredCMW) Anomalies() LayerAnomaly {
+	var a LayerAnomaly
+	for _, l := range o {
+		a |= l.Anomalies
+	}
+	return a
+}
+
+// Collection synthesizes a Collection CMW (with the supplied __cmwc_t, which
+// can be empty) whose items are keyed by DICE layer number.  If a certificate
+// carries more than one CMW, the corresponding item is a nested collection
+// keyed by occurrence.
+func (o LayeredCMW) Collection(cmwct string) (*CMW, error) {
+	if len(o) == 0 {
+		return nil, errors.New("no CMW layers")
+	}
+
+	root, err := NewCollection(cmwct)
+	if err != nil {
+		return nil, err
+	}
+
+	byLayer := make(map[int][]CMWLayer)
+	for _, l := range o {
+		byLayer[l.Layer] = append(byLayer[l.Layer], l)
+	}
+
+	for layer, ls := range byLayer {
+		node := ls[0].CMW
+
+		if len(ls) > 1 {
+			if node, err = NewCollection(""); err != nil {
+				return nil, err
+			}
+			for _, l := range ls {
+				if err := node.AddCollectionItem(uint64(l.Occurrence), l.CMW); err != nil {
+					return nil, fmt.Errorf("layer %d, occurrence %d: %w", layer, l.Occurrence, err)
+				}
+			}
+		}
+
+		if err := root.AddCollectionItem(uint64(layer), node); err != nil {
+			return nil, fmt.Errorf("layer %d: %w", layer, err)
+		}
+	}
+
+	return root, nil
+}
+
+// VerifyX509Certificate is like leaf.Verify(opts), except that critical
+// id-pe-cmw extensions in the leaf and in the supplied intermediates are
+// treated as handled, provided that they successfully decode.  This makes it
+// possible for relying parties to accept certificates produced with
+// EncodeX509Extension(…, true).  The intermediates are added to
+// opts.Intermediates (which can be nil).  Note that the returned chains contain
+// copies of the leaf and intermediates, not the original certificates.
+//
+// On success, the CMW carried by the leaf (nil if the leaf has no id-pe-cmw
+// extension) is returned together with the verified chains.
+func VerifyX509Certificate(
+	leaf *x509.Certificate, intermediates []*x509.Certificate, opts x509.VerifyOptions,
+) (*CMW, [][]*x509.Certificate, error) {
+	if leaf == nil {
+		return nil, nil, errors.New("nil leaf certificate")
+	}
+
+	handledLeaf, c, err := handleCMWExtension(leaf)
+	if err != nil {
+		return nil, nil, fmt.Errorf("leaf certificate: %w", err)
+	}
+
+	if len(intermediates) > 0 {
+		if opts.Intermediates != nil {
+			opts.Intermediates = opts.Intermediates.Clone()
+		} else {
+			opts.Intermediates = x509.NewCertPool()
+		}
+
+		for i, cert := range intermediates {
+			handled, _, err := handleCMWExtension(cert)
+			if err != nil {
+				return nil, nil, fmt.Errorf("intermediate certificate %d: %w", i, err)
+			}
+			opts.Intermediates.AddCert(handled)
+		}
+	}
+
+	chains, err := handledLeaf.Verify(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c, chains, nil
+}
+
+// handleCMWExtension decodes the id-pe-cmw extension in cert (if any) and
+// returns a shallow copy of cert in which the extension is no longer listed as
+// an unhandled critical extension
+func handleCMWExtension(cert *x509.Certificate) (*x509.Certificate, *CMW, error) {
+	if cert == nil {
+		return nil, nil, errors.New("nil certificate")
+	}
+
+	var c *CMW
+
+	for _, extn := range cert.Extensions {
+		if !extn.Id.Equal(OidExtCmw) {
+			continue
+		}
+
+		if c != nil {
+			return nil, nil, errors.New("more than one id-pe-cmw extension")
+		}
+
+		var err error
+		if c, err = DecodeX509Extension(extn); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	handled := *cert
+	handled.UnhandledCriticalExtensions = nil
+
+	for _, oid := range cert.UnhandledCriticalExtensions {
+		if c != nil && oid.Equal(OidExtCmw) {
+			continue
+		}
+		handled.UnhandledCriticalExtensions = append(handled.UnhandledCriticalExtensions, oid)
+	}
+
+	return &handled, c, nil
+}
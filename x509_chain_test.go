@@ -0,0 +1,214 @@
+// Copyright 2025 Contributors to the Veraison project.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmw
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeTestCert issues a certificate for a new key, signed by parentKey (or
+// self-signed if parent is nil) and carrying the supplied extensions
+func makeTestCert(
+	t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, extns ...pkix.Extension,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtraExtensions:       extns,
+	}
+
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func mustX509Extension(t *testing.T, c *CMW, critical bool) pkix.Extension {
+	extn, err := c.EncodeX509Extension(ChoiceCbor, critical)
+	require.NoError(t, err)
+	return *extn
+}
+
+func Test_DecodeX509Chain_ok(t *testing.T) {
+	l0 := mustNewMonad(t, "application/eat-ucs+cbor", []byte{0xa1, 0x0a, 0}, Evidence)
+	l2 := mustNewMonad(t, "application/eat-ucs+cbor", []byte{0xa1, 0x0a, 2}, Evidence)
+
+	root, rootKey := makeTestCert(t, "layer 0", true, nil, nil, mustX509Extension(t, l0, false))
+	inter, interKey := makeTestCert(t, "layer 1", true, root, rootKey)
+	leaf, _ := makeTestCert(t, "layer 2", false, inter, interKey, mustX509Extension(t, l2, true))
+
+	layers, err := DecodeX509Chain([]*x509.Certificate{leaf, inter, root})
+	require.NoError(t, err)
+	require.Len(t, layers, 2)
+
+	assert.Equal(t, 0, layers[0].Layer)
+	assert.Equal(t, 2, layers[0].CertIndex)
+	assert.Equal(t, root, layers[0].Cert)
+	assert.Equal(t, LayerAnomalyNone, layers[0].Anomalies)
+	v, _ := layers[0].CMW.GetMonadValue()
+	assert.Equal(t, []byte{0xa1, 0x0a, 0x00}, v)
+
+	assert.Equal(t, 2, layers[1].Layer)
+	assert.Equal(t, 0, layers[1].CertIndex)
+	assert.Equal(t, leaf, layers[1].Cert)
+	assert.Equal(t, LayerAnomalyCritical, layers[1].Anomalies)
+
+	assert.Equal(t, "critical", layers.Anomalies().String())
+
+	c, err := layers.Collection("tag:example.com,2025:dice")
+	require.NoError(t, err)
+
+	meta, err := c.GetCollectionMeta()
+	require.NoError(t, err)
+	assert.Equal(t, []Meta{{uint64(0), KindMonad}, {uint64(2), KindMonad}}, meta)
+
+	item, err := c.GetCollectionItem(uint64(2))
+	require.NoError(t, err)
+	v, _ = item.GetMonadValue()
+	assert.Equal(t, []byte{0xa1, 0x0a, 0x02}, v)
+}
+
+func Test_DecodeX509Chain_duplicates(t *testing.T) {
+	a := mustX509Extension(t, mustNewMonad(t, "application/eat-ucs+cbor", []byte{0xa1, 0x0a, 0}, Evidence), false)
+	b := mustX509Extension(t, mustNewMonad(t, "application/eat-ucs+cbor", []byte{0xa1, 0x0a, 1}, Endorsements), false)
+
+	root, rootKey := makeTestCert(t, "layer 0", true, nil, nil, a)
+	leaf, _ := makeTestCert(t, "layer 1", false, root, rootKey, a)
+
+	// the standard library refuses to parse certificates with duplicate
+	// extensions, so craft one by hand
+	leaf.Extensions = append(leaf.Extensions, b)
+
+	layers, err := DecodeX509Chain([]*x509.Certificate{leaf, root})
+	require.NoError(t, err)
+	require.Len(t, layers, 3)
+
+	assert.Equal(t, LayerAnomalyNone, layers[0].Anomalies)
+	assert.Equal(t, LayerAnomalyDuplicate|LayerAnomalyRepeated, layers[1].Anomalies)
+	assert.Equal(t, 0, layers[1].Occurrence)
+	assert.Equal(t, LayerAnomalyDuplicate, layers[2].Anomalies)
+	assert.Equal(t, 1, layers[2].Occurrence)
+
+	c, err := layers.Collection("")
+	require.NoError(t, err)
+
+	item, err := c.GetCollectionItem(uint64(1))
+	require.NoError(t, err)
+	assert.Equal(t, KindCollection, item.GetKind())
+
+	meta, err := item.GetCollectionMeta()
+	require.NoError(t, err)
+	assert.Equal(t, []Meta{{uint64(0), KindMonad}, {uint64(1), KindMonad}}, meta)
+}
+
+func Test_DecodeX509Chain_ko(t *testing.T) {
+	_, err := DecodeX509Chain(nil)
+	assert.EqualError(t, err, "empty certificate chain")
+
+	_, err = DecodeX509Chain([]*x509.Certificate{nil})
+	assert.EqualError(t, err, "certificate 0: nil certificate")
+
+	bad := &x509.Certificate{
+		Extensions: []pkix.Extension{{Id: OidExtCmw, Value: []byte{0x05, 0x00}}},
+	}
+
+	_, err = DecodeX509Chain([]*x509.Certificate{bad})
+	assert.EqualError(t, err, "certificate 0: expecting OCTET STRING or UTF8String, got <nil>")
+
+	_, err = LayeredCMW(nil).Collection("")
+	assert.EqualError(t, err, "no CMW layers")
+}
+
+func Test_VerifyX509Certificate_critical_ok(t *testing.T) {
+	mi := mustNewMonad(t, "application/eat-ucs+cbor", []byte{0xa1, 0x0a, 1}, Endorsements)
+	ml := mustNewMonad(t, "application/eat-ucs+cbor", []byte{0xa1, 0x0a, 2}, Evidence)
+
+	root, rootKey := makeTestCert(t, "root", true, nil, nil)
+	inter, interKey := makeTestCert(t, "inter", true, root, rootKey, mustX509Extension(t, mi, true))
+	leaf, _ := makeTestCert(t, "leaf", false, inter, interKey, mustX509Extension(t, ml, true))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	// the standard library does not know about id-pe-cmw
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(inter)
+	_, err := leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+	assert.ErrorAs(t, err, &x509.UnhandledCriticalExtension{})
+
+	c, chains, err := VerifyX509Certificate(leaf, []*x509.Certificate{inter}, x509.VerifyOptions{Roots: roots})
+	require.NoError(t, err)
+	require.Len(t, chains, 1)
+	assert.Len(t, chains[0], 3)
+
+	v, err := c.GetMonadValue()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xa1, 0x0a, 0x02}, v)
+
+	// the originals are untouched
+	assert.Equal(t, []asn1.ObjectIdentifier{OidExtCmw}, leaf.UnhandledCriticalExtensions)
+}
+
+func Test_VerifyX509Certificate_no_cmw(t *testing.T) {
+	root, rootKey := makeTestCert(t, "root", true, nil, nil)
+	leaf, _ := makeTestCert(t, "leaf", false, root, rootKey)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	c, chains, err := VerifyX509Certificate(leaf, nil, x509.VerifyOptions{Roots: roots})
+	require.NoError(t, err)
+	assert.Nil(t, c)
+	assert.Len(t, chains, 1)
+}
+
+func Test_VerifyX509Certificate_ko(t *testing.T) {
+	root, rootKey := makeTestCert(t, "root", true, nil, nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	// a critical extension that is not id-pe-cmw is still unhandled
+	other := pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Critical: true, Value: []byte{0x05, 0x00}}
+	leaf, _ := makeTestCert(t, "leaf", false, root, rootKey, other, mustX509Extension(t, mustNewMonad(t, "application/eat-ucs+cbor", []byte{0xa1, 0x0a, 0}, Evidence), true))
+
+	_, _, err := VerifyX509Certificate(leaf, nil, x509.VerifyOptions{Roots: roots})
+	assert.ErrorAs(t, err, &x509.UnhandledCriticalExtension{})
+
+	// an id-pe-cmw extension that does not decode is not handled
+	bad := pkix.Extension{Id: OidExtCmw, Critical: true, Value: []byte{0x04, 0x01, 0x00}}
+	leaf, _ = makeTestCert(t, "leaf", false, root, rootKey, bad)
+
+	_, _, err = VerifyX509Certificate(leaf, nil, x509.VerifyOptions{Roots: roots})
+	assert.ErrorContains(t, err, "leaf certificate: decoding the wrapped CMW")
+
+	_, _, err = VerifyX509Certificate(nil, nil, x509.VerifyOptions{Roots: roots})
+	assert.EqualError(t, err, "nil leaf certificate")
+}